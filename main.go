@@ -1,12 +1,21 @@
 package main
 
 import (
+	"ani4s/src/cli"
 	"ani4s/src/config"
+	"ani4s/src/health"
+	"ani4s/src/logger"
+	"ani4s/src/middlewares"
 	"ani4s/src/routes"
 	"ani4s/src/services"
+	"ani4s/src/tracing"
+	"context"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -14,28 +23,97 @@ import (
 	"github.com/joho/godotenv"
 )
 
+var mainLog = logger.Named("main")
+
 func main() {
-	if _, err := os.Stat(".env"); err == nil {
-		err := godotenv.Load()
-		if err != nil {
-			log.Println("⚠️  Could not load .env files")
+	// .env is loaded first so every setting can come from it
+	var envErr error
+	_, statErr := os.Stat(".env")
+	if statErr == nil {
+		envErr = godotenv.Load()
+	}
+	settings, configErr := config.Load()
+	logger.Init(settings.Log.Level, settings.Log.Format)
+
+	ctx := context.Background()
+	if statErr == nil {
+		if envErr != nil {
+			mainLog.Warn(ctx, "could not load .env file", "error", envErr)
 		} else {
-			log.Println("✅ Loaded .env files")
+			mainLog.Info(ctx, "loaded .env file")
 		}
 	}
-	env := os.Getenv("NODE_ENV")
-	log.Printf("NODE_ENV = %s", env)
 
-	host := os.Getenv("HOST")
-	port := os.Getenv("APP_PORT")
-	if host == "" {
-		host = "0.0.0.0"
+	os.Exit(run(ctx, os.Args[1:], settings, configErr))
+}
+
+// startServer is serve, replaced in tests
+var startServer = serve
+
+// run dispatches the command line and returns the exit code
+func run(ctx context.Context, args []string, settings *config.Settings, configErr error) int {
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
 	}
-	if port == "" {
-		port = "2000"
+
+	// `ani4s --health-check` is the container HEALTHCHECK: it asks the running server, it does not serve
+	if arg == "--health-check" {
+		if err := health.Probe(fmt.Sprintf("127.0.0.1:%d", settings.Server.Port)); err != nil {
+			fmt.Fprintln(os.Stderr, "unhealthy:", err)
+			return 1
+		}
+		return 0
 	}
+
+	// help, -h and --help only print the commands, so they work with a broken configuration
+	if cli.IsHelp(arg) {
+		cli.Usage()
+		return 0
+	}
+
+	// Every invalid setting is reported at once, before anything connects
+	if configErr != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", configErr)
+		return 1
+	}
+
+	// Any other flag is a typo, not a reason to serve
+	if strings.HasPrefix(arg, "-") {
+		cli.Usage()
+		fmt.Fprintf(os.Stderr, "unknown flag %s\n", arg)
+		return 2
+	}
+
+	// Spans are exported when the tracing exporter is otlp or stdout
+	if err := tracing.Init(ctx, settings.Tracing.Exporter); err != nil {
+		mainLog.Warn(ctx, "tracing disabled", "error", err)
+	}
+
+	// `ani4s <command>` runs a maintenance subcommand, no command (or `serve`) starts the server
+	if arg != "" && arg != "serve" {
+		err := cli.Run(arg, args[1:])
+		_ = tracing.Shutdown(ctx)
+		if err != nil {
+			mainLog.Error(ctx, "command failed", "command", arg, "error", err)
+			return 1
+		}
+		return 0
+	}
+
+	startServer()
+	return 0
+}
+
+// serve starts the HTTP server and the background jobs
+func serve() {
+	server := config.App.Server
+	mainLog.Info(context.Background(), "starting server", "node_env", config.App.Env, "config_file", config.App.File)
+
 	// Setup Gin router
-	router := gin.Default()
+	// Every request gets a trace span, an id, a latency sample, one access log record and panic recovery
+	router := gin.New()
+	router.Use(middlewares.Tracing(), middlewares.RequestID(), middlewares.Metrics(), middlewares.AccessLog(), middlewares.Recovery())
 	// Enable CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -57,8 +135,57 @@ func main() {
 	routes.RegisterRoutes(router)
 	services.SetupBackgroundJobs()
 
-	addr := fmt.Sprintf("%s:%s", host, port)
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Could not start server: %v\n", err)
+	addr := fmt.Sprintf("%s:%d", server.Host, server.Port)
+	srv := &http.Server{Addr: addr, Handler: router, ReadHeaderTimeout: 10 * time.Second}
+	// Hijacked WebSocket connections are not drained by Shutdown: close them as it starts
+	srv.RegisterOnShutdown(func() {
+		services.CloseWebSockets(context.Background())
+	})
+
+	// SIGINT (Ctrl-C) or SIGTERM (docker stop, Kubernetes) starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	mainLog.Info(ctx, "listening", "addr", addr)
+
+	select {
+	case err := <-serveErr:
+		mainLog.Fatal(ctx, "could not start server", "addr", addr, "error", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away
+	stop()
+	shutdown(srv, server.ShutdownTimeout)
+}
+
+// shutdown stops within timeout: in-flight requests (image downloads included) finish,
+// WebSocket clients are told the server is restarting, running jobs finish, then the
+// Redis and database clients are closed and the buffered spans flushed
+func shutdown(srv *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	mainLog.Info(ctx, "shutting down", "drain_timeout", timeout.String())
+
+	if err := srv.Shutdown(ctx); err != nil {
+		mainLog.Warn(ctx, "requests still in flight at shutdown", "error", err)
+	}
+	_ = services.StopBackgroundJobs(ctx)
+
+	if err := config.CloseRedis(); err != nil {
+		mainLog.Warn(ctx, "failed to close Redis client", "error", err)
+	}
+	if err := config.CloseDatabase(); err != nil {
+		mainLog.Warn(ctx, "failed to close database", "error", err)
+	}
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := tracing.Shutdown(flushCtx); err != nil {
+		mainLog.Warn(ctx, "failed to flush traces", "error", err)
 	}
+	mainLog.Info(ctx, "server stopped")
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"ani4s/src/config"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	invalid := errors.New("database.user (DB_USER) is required")
+
+	tests := []struct {
+		name      string
+		args      []string
+		configErr error
+		code      int
+		serves    bool
+	}{
+		{"no arguments", nil, nil, 0, true},
+		{"serve", []string{"serve"}, nil, 0, true},
+		{"invalid config", nil, invalid, 1, false},
+		{"invalid config and serve", []string{"serve"}, invalid, 1, false},
+		{"help", []string{"help"}, nil, 0, false},
+		{"-h with invalid config", []string{"-h"}, invalid, 0, false},
+		{"--help with invalid config", []string{"--help"}, invalid, 0, false},
+		{"unknown flag", []string{"--port=80"}, nil, 2, false},
+		{"unknown flag with invalid config", []string{"-v"}, invalid, 1, false},
+		{"unknown command", []string{"deploy"}, nil, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served := false
+			startServer = func() { served = true }
+			t.Cleanup(func() { startServer = serve })
+
+			if code := run(context.Background(), tt.args, config.Defaults(), tt.configErr); code != tt.code {
+				t.Errorf("run(%q) = %d, want %d", tt.args, code, tt.code)
+			}
+			if served != tt.serves {
+				t.Errorf("run(%q) served = %v, want %v", tt.args, served, tt.serves)
+			}
+		})
+	}
+}
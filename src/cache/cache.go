@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is absent or expired
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores opaque payloads with a TTL and indexes them in tag sets ("<tag>:cached_keys")
+// so a whole family of keys can be listed or flushed at once
+type Cache interface {
+	// Get returns the payload stored under key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetTagged stores value under key and adds key to the set tagKey, which lives for tagTTL
+	SetTagged(ctx context.Context, key string, value []byte, ttl time.Duration, tagKey string, tagTTL time.Duration) error
+	// Del removes payloads and tag sets, returning how many existed
+	Del(ctx context.Context, keys ...string) (int64, error)
+	// Members lists the keys indexed in the set tagKey
+	Members(ctx context.Context, tagKey string) ([]string, error)
+	// Untag removes keys from the set tagKey
+	Untag(ctx context.Context, tagKey string, keys ...string) error
+}
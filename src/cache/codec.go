@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Payloads written by a Codec start with a 4 byte header: magic, format version,
+// encoding and compression. Entries without it were written before the codec existed:
+// they are read as plain JSON (or raw bytes for images) until they expire.
+const (
+	headerMagic   byte = 0xC5
+	headerVersion byte = 1
+	headerSize         = 4
+)
+
+// Encoding is how a value is serialized
+type Encoding byte
+
+const (
+	EncodingRaw Encoding = iota // opaque bytes (images)
+	EncodingJSON
+	EncodingMsgpack
+)
+
+// Compression is applied to the serialized value
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionSnappy
+	CompressionZstd
+)
+
+var (
+	encodingNames    = map[string]Encoding{"json": EncodingJSON, "msgpack": EncodingMsgpack}
+	compressionNames = map[string]Compression{"none": CompressionNone, "snappy": CompressionSnappy, "zstd": CompressionZstd}
+)
+
+// zstd encoders and decoders are safe for concurrent EncodeAll/DecodeAll calls
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+var errUnknownFormat = errors.New("unknown cache payload format")
+
+// Codec writes cache payloads. Payloads shorter than MinCompress, or that do not shrink,
+// are stored uncompressed.
+type Codec struct {
+	Encoding    Encoding
+	Compression Compression
+	MinCompress int
+}
+
+// DefaultCodec is msgpack, zstd-compressed from 1 KiB
+var DefaultCodec = Codec{Encoding: EncodingMsgpack, Compression: CompressionZstd, MinCompress: 1024}
+
+// ParseCodec builds a codec from names: json or msgpack, and none, snappy or zstd
+func ParseCodec(encoding, compression string) (Codec, error) {
+	c := DefaultCodec
+	if encoding != "" {
+		e, ok := encodingNames[strings.ToLower(encoding)]
+		if !ok {
+			return c, fmt.Errorf("unknown cache encoding %q (want json or msgpack)", encoding)
+		}
+		c.Encoding = e
+	}
+	if compression != "" {
+		z, ok := compressionNames[strings.ToLower(compression)]
+		if !ok {
+			return c, fmt.Errorf("unknown cache compression %q (want none, snappy or zstd)", compression)
+		}
+		c.Compression = z
+	}
+	return c, nil
+}
+
+// Marshal serializes v with the codec encoding and compresses it
+func (c Codec) Marshal(v interface{}) ([]byte, error) {
+	var body []byte
+	var err error
+	switch c.Encoding {
+	case EncodingMsgpack:
+		body, err = marshalMsgpack(v)
+	default:
+		body, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	encoding := c.Encoding
+	if encoding != EncodingMsgpack {
+		encoding = EncodingJSON
+	}
+	return c.pack(encoding, body), nil
+}
+
+// MarshalRaw stores opaque bytes, compressed when that pays off
+func (c Codec) MarshalRaw(raw []byte) []byte {
+	return c.pack(EncodingRaw, raw)
+}
+
+func (c Codec) pack(encoding Encoding, body []byte) []byte {
+	compression := CompressionNone
+	if c.Compression != CompressionNone && len(body) >= c.MinCompress {
+		var packed []byte
+		switch c.Compression {
+		case CompressionSnappy:
+			packed = snappy.Encode(nil, body)
+		case CompressionZstd:
+			packed = zstdEncoder.EncodeAll(body, nil)
+		}
+		if packed != nil && len(packed) < len(body) {
+			body, compression = packed, c.Compression
+		}
+	}
+
+	out := make([]byte, headerSize, headerSize+len(body))
+	out[0], out[1], out[2], out[3] = headerMagic, headerVersion, byte(encoding), byte(compression)
+	return append(out, body...)
+}
+
+// Unmarshal decodes a payload written by any codec, or a legacy plain JSON one, into v
+func Unmarshal(b []byte, v interface{}) error {
+	encoding, body, err := unpack(b, EncodingJSON)
+	if err != nil {
+		return err
+	}
+	switch encoding {
+	case EncodingJSON:
+		return json.Unmarshal(body, v)
+	case EncodingMsgpack:
+		return unmarshalMsgpack(body, v)
+	default:
+		return fmt.Errorf("%w: encoding %d", errUnknownFormat, encoding)
+	}
+}
+
+// ToJSON renders any payload as JSON, for inspection and searching. Raw payloads are
+// rendered as a JSON string.
+func ToJSON(b []byte) ([]byte, error) {
+	encoding, body, err := unpack(b, EncodingJSON)
+	if err != nil {
+		return nil, err
+	}
+	switch encoding {
+	case EncodingJSON:
+		if json.Valid(body) {
+			return body, nil
+		}
+		return json.Marshal(string(body))
+	case EncodingMsgpack:
+		var v interface{}
+		if err := unmarshalMsgpack(body, &v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	default:
+		return json.Marshal(string(body))
+	}
+}
+
+// UnmarshalRaw returns the bytes stored by MarshalRaw, or a legacy raw payload as is
+func UnmarshalRaw(b []byte) ([]byte, error) {
+	_, body, err := unpack(b, EncodingRaw)
+	return body, err
+}
+
+// unpack strips the header and decompresses the body; headerless payloads are legacy
+func unpack(b []byte, legacy Encoding) (Encoding, []byte, error) {
+	if len(b) < headerSize || b[0] != headerMagic {
+		return legacy, b, nil
+	}
+	if b[1] != headerVersion {
+		return 0, nil, fmt.Errorf("%w: version %d", errUnknownFormat, b[1])
+	}
+
+	encoding, body := Encoding(b[2]), b[headerSize:]
+	switch Compression(b[3]) {
+	case CompressionNone:
+		return encoding, body, nil
+	case CompressionSnappy:
+		out, err := snappy.Decode(nil, body)
+		return encoding, out, err
+	case CompressionZstd:
+		out, err := zstdDecoder.DecodeAll(body, nil)
+		return encoding, out, err
+	default:
+		return 0, nil, fmt.Errorf("%w: compression %d", errUnknownFormat, b[3])
+	}
+}
+
+// msgpack follows the json tags so cached structs keep one set of field names
+func marshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalMsgpack(b []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
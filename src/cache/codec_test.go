@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type payload struct {
+	Slug   string   `json:"slug"`
+	Name   string   `json:"name"`
+	Year   int      `json:"year"`
+	Tags   []string `json:"tags"`
+	Hidden bool     `json:"hidden,omitempty"`
+}
+
+func samplePayload(size int) payload {
+	return payload{
+		Slug: "one-piece",
+		Name: strings.Repeat("One Piece ", size/10+1),
+		Year: 1999,
+		Tags: []string{"anime", "adventure"},
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, encoding := range []string{"json", "msgpack"} {
+		for _, compression := range []string{"none", "snappy", "zstd"} {
+			for _, size := range []int{10, 4096} {
+				codec, err := ParseCodec(encoding, compression)
+				if err != nil {
+					t.Fatal(err)
+				}
+				name := encoding + "/" + compression
+				in := samplePayload(size)
+
+				b, err := codec.Marshal(in)
+				if err != nil {
+					t.Fatalf("%s: Marshal: %v", name, err)
+				}
+				if b[0] != headerMagic || b[1] != headerVersion {
+					t.Errorf("%s: missing header: % x", name, b[:headerSize])
+				}
+				wantCompressed := compression != "none" && size > codec.MinCompress
+				if got := Compression(b[3]) != CompressionNone; got != wantCompressed {
+					t.Errorf("%s, %d bytes: compressed = %v, want %v", name, size, got, wantCompressed)
+				}
+
+				var out payload
+				if err := Unmarshal(b, &out); err != nil {
+					t.Fatalf("%s: Unmarshal: %v", name, err)
+				}
+				if !reflect.DeepEqual(out, in) {
+					t.Errorf("%s: round trip = %+v, want %+v", name, out, in)
+				}
+
+				js, err := ToJSON(b)
+				if err != nil {
+					t.Fatalf("%s: ToJSON: %v", name, err)
+				}
+				var fromJSON payload
+				if err := json.Unmarshal(js, &fromJSON); err != nil || !reflect.DeepEqual(fromJSON, in) {
+					t.Errorf("%s: ToJSON = %s (%v)", name, js, err)
+				}
+			}
+		}
+	}
+}
+
+func TestUnmarshalLegacyJSON(t *testing.T) {
+	in := samplePayload(10)
+	legacy, _ := json.Marshal(in)
+
+	var out payload
+	if err := Unmarshal(legacy, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("legacy JSON = %+v, want %+v", out, in)
+	}
+	if js, err := ToJSON(legacy); err != nil || !bytes.Equal(js, legacy) {
+		t.Errorf("ToJSON(legacy) = %s, %v", js, err)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	small := []byte("\x89PNG tiny")
+	large := bytes.Repeat([]byte("jpeg"), 1024)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		raw     []byte
+	}{
+		{"small", DefaultCodec.MarshalRaw(small), small},
+		{"compressed", DefaultCodec.MarshalRaw(large), large},
+		{"snappy", Codec{Compression: CompressionSnappy}.MarshalRaw(large), large},
+		{"legacy", small, small},
+		{"legacy shorter than a header", []byte("ab"), []byte("ab")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalRaw(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.raw) {
+				t.Errorf("UnmarshalRaw = %q, want %q", got, tt.raw)
+			}
+		})
+	}
+
+	if js, err := ToJSON(DefaultCodec.MarshalRaw([]byte("GIF89a"))); err != nil || string(js) != `"GIF89a"` {
+		t.Errorf("ToJSON(raw) = %s, %v", js, err)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"version", []byte{headerMagic, headerVersion + 1, byte(EncodingJSON), byte(CompressionNone), '{', '}'}},
+		{"compression", []byte{headerMagic, headerVersion, byte(EncodingJSON), 9, '{', '}'}},
+		{"encoding", []byte{headerMagic, headerVersion, byte(EncodingRaw), byte(CompressionNone), '{', '}'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := Unmarshal(tt.payload, &v); !errors.Is(err, errUnknownFormat) {
+				t.Errorf("Unmarshal error = %v, want %v", err, errUnknownFormat)
+			}
+		})
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	tests := []struct {
+		encoding, compression string
+		want                  Codec
+		wantErr               bool
+	}{
+		{"", "", DefaultCodec, false},
+		{"JSON", "none", Codec{EncodingJSON, CompressionNone, DefaultCodec.MinCompress}, false},
+		{"msgpack", "Snappy", Codec{EncodingMsgpack, CompressionSnappy, DefaultCodec.MinCompress}, false},
+		{"xml", "", DefaultCodec, true},
+		{"", "lz4", DefaultCodec, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCodec(tt.encoding, tt.compression)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCodec(%q, %q) error = %v, want error %v", tt.encoding, tt.compression, err, tt.wantErr)
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseCodec(%q, %q) = %+v, want %+v", tt.encoding, tt.compression, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var cacheLog = logger.Named("cache")
+
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+	reconnectTimeout    = 2 * time.Second
+)
+
+// Fallback serves from Redis and switches to an in-process LRU as soon as a Redis call
+// fails to reach the server. Redis is then pinged in the background with exponential
+// backoff, and used again once it answers. Requests keep being served from Postgres and
+// phimapi meanwhile, with each replica caching on its own.
+type Fallback struct {
+	client redis.Cmdable
+	redis  *Redis
+	memory *Memory
+
+	down atomic.Bool
+}
+
+// NewFallback returns a cache over client that falls back to memory while Redis is unreachable
+func NewFallback(client redis.Cmdable, memory *Memory) *Fallback {
+	return &Fallback{client: client, redis: NewRedis(client), memory: memory}
+}
+
+// Degraded reports whether the in-process cache is serving instead of Redis
+func (f *Fallback) Degraded() bool {
+	return f.down.Load()
+}
+
+// use returns the cache to serve from
+func (f *Fallback) use() Cache {
+	if f.down.Load() {
+		return f.memory
+	}
+	return f.redis
+}
+
+// check switches to memory when err means Redis could not be reached. It returns
+// whether the call should be retried on memory.
+func (f *Fallback) check(ctx context.Context, err error) bool {
+	if !unreachable(err) {
+		return false
+	}
+	if f.down.CompareAndSwap(false, true) {
+		metrics.CacheFallbackActive.Set(1)
+		cacheLog.Error(ctx, "Redis unreachable, serving from the in-process cache", "error", err)
+		go f.reconnect()
+	}
+	return true
+}
+
+// reconnect pings Redis until it answers, then switches back to it. Only the call that
+// flipped down starts it, so one loop runs per outage.
+func (f *Fallback) reconnect() {
+	backoff := reconnectMinBackoff
+	for {
+		time.Sleep(backoff)
+		ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+		err := f.client.Ping(ctx).Err()
+		cancel()
+		if err == nil {
+			break
+		}
+		cacheLog.Debug(context.Background(), "Redis still unreachable", "retry_in", backoff.String(), "error", err)
+		if backoff *= 2; backoff > reconnectMaxBackoff {
+			backoff = reconnectMaxBackoff
+		}
+	}
+
+	// Entries written during the outage may have been invalidated on Redis since:
+	// start the next outage from an empty LRU
+	f.memory.Purge()
+	f.down.Store(false)
+	metrics.CacheFallbackActive.Set(0)
+	cacheLog.Info(context.Background(), "Redis is reachable again, serving from Redis")
+}
+
+func (f *Fallback) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := f.use().Get(ctx, key)
+	if f.check(ctx, err) {
+		return f.memory.Get(ctx, key)
+	}
+	return b, err
+}
+
+func (f *Fallback) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := f.use().Set(ctx, key, value, ttl)
+	if f.check(ctx, err) {
+		return f.memory.Set(ctx, key, value, ttl)
+	}
+	return err
+}
+
+func (f *Fallback) SetTagged(ctx context.Context, key string, value []byte, ttl time.Duration, tagKey string, tagTTL time.Duration) error {
+	err := f.use().SetTagged(ctx, key, value, ttl, tagKey, tagTTL)
+	if f.check(ctx, err) {
+		return f.memory.SetTagged(ctx, key, value, ttl, tagKey, tagTTL)
+	}
+	return err
+}
+
+func (f *Fallback) Del(ctx context.Context, keys ...string) (int64, error) {
+	n, err := f.use().Del(ctx, keys...)
+	if f.check(ctx, err) {
+		return f.memory.Del(ctx, keys...)
+	}
+	return n, err
+}
+
+func (f *Fallback) Members(ctx context.Context, tagKey string) ([]string, error) {
+	keys, err := f.use().Members(ctx, tagKey)
+	if f.check(ctx, err) {
+		return f.memory.Members(ctx, tagKey)
+	}
+	return keys, err
+}
+
+func (f *Fallback) Untag(ctx context.Context, tagKey string, keys ...string) error {
+	err := f.use().Untag(ctx, tagKey, keys...)
+	if f.check(ctx, err) {
+		return f.memory.Untag(ctx, tagKey, keys...)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DelKeys deletes keys with one DEL per key in a pipeline: on Redis Cluster a multi-key
+// DEL fails when the keys live in different hash slots, while pipelined commands are
+// routed to the node of each key
+func DelKeys(ctx context.Context, client redis.Cmdable, keys ...string) (int64, error) {
+	switch len(keys) {
+	case 0:
+		return 0, nil
+	case 1:
+		return client.Del(ctx, keys[0]).Result()
+	}
+
+	pipe := client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Del(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+
+	var removed int64
+	for _, cmd := range cmds {
+		removed += cmd.Val()
+	}
+	return removed, err
+}
+
+// ScanKeys calls fn with every key matching pattern. On Redis Cluster every master is
+// scanned, one SCAN only walks the node it is sent to; fn is never called concurrently.
+func ScanKeys(ctx context.Context, client redis.UniversalClient, pattern string, fn func(key string) error) error {
+	scan := func(ctx context.Context, c redis.Cmdable) error {
+		iter := c.Scan(ctx, 0, pattern, 500).Iterator()
+		for iter.Next(ctx) {
+			if err := fn(iter.Val()); err != nil {
+				return err
+			}
+		}
+		return iter.Err()
+	}
+
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return scan(ctx, client)
+	}
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return scan(ctx, node)
+	})
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+)
+
+// Memory is an in-process LRU bounded by entry count and by the total size of its payloads
+type Memory struct {
+	mu       sync.Mutex
+	lru      *simplelru.LRU[string, *entry]
+	bytes    int64
+	maxBytes int64
+}
+
+// entry is a payload or, when members is set, a tag set
+type entry struct {
+	value   []byte
+	members map[string]struct{}
+	expires time.Time
+	bytes   int64 // size accounted when it was stored
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+func (e *entry) size() int64 {
+	n := int64(len(e.value))
+	for m := range e.members {
+		n += int64(len(m))
+	}
+	return n
+}
+
+// NewMemory returns an LRU holding at most maxEntries entries and maxBytes of payloads
+func NewMemory(maxEntries int, maxBytes int64) *Memory {
+	m := &Memory{maxBytes: maxBytes}
+	m.lru, _ = simplelru.NewLRU(maxEntries, func(_ string, e *entry) {
+		m.bytes -= e.bytes
+	})
+	return m
+}
+
+// Len returns the number of entries, expired ones included until they are touched or evicted
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Bytes returns the size of the payloads held
+func (m *Memory) Bytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Purge drops every entry
+func (m *Memory) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lru.Purge()
+}
+
+// lookup returns the live entry of key, dropping it when expired; callers hold mu
+func (m *Memory) lookup(key string) (*entry, bool) {
+	e, ok := m.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if e.expired(time.Now()) {
+		m.lru.Remove(key)
+		return nil, false
+	}
+	return e, true
+}
+
+// put stores e under key and evicts the least recently used entries over the byte budget; callers hold mu
+func (m *Memory) put(key string, e *entry) {
+	m.lru.Remove(key)
+	e.bytes = e.size()
+	if e.bytes > m.maxBytes {
+		return
+	}
+	m.lru.Add(key, e)
+	m.bytes += e.bytes
+	for m.bytes > m.maxBytes {
+		if _, _, ok := m.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.lookup(key)
+	if !ok || e.members != nil {
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.put(key, &entry{value: value, expires: expiry(ttl)})
+	return nil
+}
+
+func (m *Memory) SetTagged(ctx context.Context, key string, value []byte, ttl time.Duration, tagKey string, tagTTL time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.put(key, &entry{value: value, expires: expiry(ttl)})
+
+	tag, ok := m.lookup(tagKey)
+	members := map[string]struct{}{}
+	if ok && tag.members != nil {
+		members = tag.members
+	}
+	members[key] = struct{}{}
+	m.put(tagKey, &entry{members: members, expires: expiry(tagTTL)})
+	return nil
+}
+
+func (m *Memory) Del(_ context.Context, keys ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, key := range keys {
+		if _, ok := m.lookup(key); ok {
+			m.lru.Remove(key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *Memory) Members(_ context.Context, tagKey string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tag, ok := m.lookup(tagKey)
+	if !ok {
+		return []string{}, nil
+	}
+	keys := make([]string, 0, len(tag.members))
+	for k := range tag.members {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (m *Memory) Untag(_ context.Context, tagKey string, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tag, ok := m.lookup(tagKey)
+	if !ok || tag.members == nil {
+		return nil
+	}
+	for _, k := range keys {
+		if _, ok := tag.members[k]; ok {
+			delete(tag.members, k)
+			tag.bytes -= int64(len(k))
+			m.bytes -= int64(len(k))
+		}
+	}
+	return nil
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
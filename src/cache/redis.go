@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is the shared cache of all replicas
+type Redis struct {
+	client redis.Cmdable
+}
+
+func NewRedis(client redis.Cmdable) *Redis {
+	return &Redis{client: client}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	return b, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) SetTagged(ctx context.Context, key string, value []byte, ttl time.Duration, tagKey string, tagTTL time.Duration) error {
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, key, value, ttl)
+	pipe.SAdd(ctx, tagKey, key)
+	pipe.Expire(ctx, tagKey, tagTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *Redis) Del(ctx context.Context, keys ...string) (int64, error) {
+	return DelKeys(ctx, r.client, keys...)
+}
+
+func (r *Redis) Members(ctx context.Context, tagKey string) ([]string, error) {
+	return r.client.SMembers(ctx, tagKey).Result()
+}
+
+func (r *Redis) Untag(ctx context.Context, tagKey string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(keys))
+	for i, k := range keys {
+		members[i] = k
+	}
+	return r.client.SRem(ctx, tagKey, members...).Err()
+}
+
+// unreachable tells connection failures (dial, timeout, closed pool) from errors
+// returned by a Redis server that answered, like WRONGTYPE. A deadline only counts
+// when the network reports it: the caller's own context running out says nothing
+// about Redis.
+func unreachable(err error) bool {
+	if err == nil || errors.Is(err, ErrMiss) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var serverErr redis.Error
+	return !errors.As(err, &serverErr)
+}
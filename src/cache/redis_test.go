@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// serverError is what a Redis server that answered returns, like WRONGTYPE
+type serverError string
+
+func (e serverError) Error() string { return string(e) }
+func (serverError) RedisError()     {}
+
+func TestUnreachable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	readTimeout := &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"miss", ErrMiss, false},
+		{"canceled", context.Canceled, false},
+		{"caller deadline", context.DeadlineExceeded, false},
+		{"wrapped caller deadline", fmt.Errorf("get: %w", context.DeadlineExceeded), false},
+		{"server error", serverError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"wrapped server error", fmt.Errorf("get: %w", serverError("LOADING")), false},
+		{"closed pool", redis.ErrClosed, true},
+		{"unknown", errors.New("EOF"), true},
+		{"dial refused", dialErr, true},
+		{"network read timeout", readTimeout, true},
+		{"dial deadline", &net.OpError{Op: "dial", Net: "tcp", Err: context.DeadlineExceeded}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unreachable(tt.err); got != tt.want {
+				t.Errorf("unreachable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel carries the keys deleted by one replica to the others
+const InvalidationChannel = "cache:invalidate"
+
+// Tiered keeps hot payloads in an in-process L1 in front of the shared L2. L1 entries
+// live at most ttl: deletes are broadcast over Redis pub/sub so every replica drops its
+// copy at once, while keys overwritten elsewhere are refreshed when their L1 entry expires.
+// Tag sets are only kept in L2.
+type Tiered struct {
+	l1      *Memory
+	l2      Cache
+	ttl     time.Duration
+	maxItem int
+	client  redis.UniversalClient
+	origin  string
+
+	l1Hits        atomic.Int64
+	l2Hits        atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+// Stats summarises the lookups served by each tier since startup
+type Stats struct {
+	L1Hits        int64   `json:"l1_hits"`
+	L2Hits        int64   `json:"l2_hits"`
+	Misses        int64   `json:"misses"`
+	L1HitRate     float64 `json:"l1_hit_rate"` // share of all lookups
+	L2HitRate     float64 `json:"l2_hit_rate"`
+	L1Entries     int     `json:"l1_entries"`
+	L1Bytes       int64   `json:"l1_bytes"`
+	Invalidations int64   `json:"invalidations"` // L1 keys dropped on broadcast from other replicas
+	L2Degraded    bool    `json:"l2_degraded"`   // Redis is unreachable and L2 is in process too
+}
+
+// StatsReporter is implemented by caches that keep tier statistics
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// invalidation is the message published on InvalidationChannel
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewTiered returns an L1 of payloads up to maxItem bytes, kept at most ttl, in front of l2.
+// client carries the invalidation broadcasts; Listen must run for them to be received.
+func NewTiered(l1 *Memory, l2 Cache, ttl time.Duration, maxItem int, client redis.UniversalClient) *Tiered {
+	return &Tiered{l1: l1, l2: l2, ttl: ttl, maxItem: maxItem, client: client, origin: logger.NewID()}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, error) {
+	if b, err := t.l1.Get(ctx, key); err == nil {
+		t.l1Hits.Add(1)
+		metrics.CacheTierLookups.WithLabelValues("l1").Inc()
+		return b, nil
+	}
+
+	b, err := t.l2.Get(ctx, key)
+	if err != nil {
+		t.misses.Add(1)
+		metrics.CacheTierLookups.WithLabelValues("miss").Inc()
+		return nil, err
+	}
+	t.l2Hits.Add(1)
+	metrics.CacheTierLookups.WithLabelValues("l2").Inc()
+	t.fill(ctx, key, b, t.ttl)
+	return b, nil
+}
+
+// fill copies a payload into L1 when it is small enough
+func (t *Tiered) fill(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if len(value) > t.maxItem {
+		return
+	}
+	if ttl <= 0 || ttl > t.ttl {
+		ttl = t.ttl
+	}
+	_ = t.l1.Set(ctx, key, value, ttl)
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := t.l2.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	t.fill(ctx, key, value, ttl)
+	return nil
+}
+
+func (t *Tiered) SetTagged(ctx context.Context, key string, value []byte, ttl time.Duration, tagKey string, tagTTL time.Duration) error {
+	if err := t.l2.SetTagged(ctx, key, value, ttl, tagKey, tagTTL); err != nil {
+		return err
+	}
+	t.fill(ctx, key, value, ttl)
+	return nil
+}
+
+func (t *Tiered) Del(ctx context.Context, keys ...string) (int64, error) {
+	_, _ = t.l1.Del(ctx, keys...)
+	n, err := t.l2.Del(ctx, keys...)
+	t.broadcast(ctx, keys)
+	return n, err
+}
+
+func (t *Tiered) Members(ctx context.Context, tagKey string) ([]string, error) {
+	return t.l2.Members(ctx, tagKey)
+}
+
+func (t *Tiered) Untag(ctx context.Context, tagKey string, keys ...string) error {
+	return t.l2.Untag(ctx, tagKey, keys...)
+}
+
+// broadcast asks the other replicas to drop keys from their L1
+func (t *Tiered) broadcast(ctx context.Context, keys []string) {
+	if len(keys) == 0 || t.l2Degraded() {
+		return
+	}
+	msg, err := json.Marshal(invalidation{Origin: t.origin, Keys: keys})
+	if err != nil {
+		return
+	}
+	if err := t.client.Publish(ctx, InvalidationChannel, msg).Err(); err != nil {
+		cacheLog.Warn(ctx, "failed to broadcast cache invalidation", "keys", len(keys), "error", err)
+	}
+}
+
+// Listen drops from L1 the keys deleted by other replicas until ctx is done. Broadcasts
+// sent while the subscription was down are lost, so L1 is purged when it comes back.
+func (t *Tiered) Listen(ctx context.Context) {
+	ps := t.client.Subscribe(ctx, InvalidationChannel)
+	defer ps.Close()
+
+	lost := false
+	backoff := reconnectMinBackoff
+	for {
+		msg, err := ps.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !lost {
+				cacheLog.Warn(ctx, "cache invalidation subscription lost", "error", err)
+			}
+			lost = true
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
+			continue
+		}
+		backoff = reconnectMinBackoff
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" && lost {
+				t.l1.Purge()
+				lost = false
+				cacheLog.Info(ctx, "cache invalidation subscription restored, L1 purged")
+			}
+		case *redis.Message:
+			var inv invalidation
+			if err := json.Unmarshal([]byte(m.Payload), &inv); err != nil || inv.Origin == t.origin {
+				continue
+			}
+			n, _ := t.l1.Del(ctx, inv.Keys...)
+			t.invalidations.Add(n)
+		}
+	}
+}
+
+func (t *Tiered) Stats() Stats {
+	s := Stats{
+		L1Hits:        t.l1Hits.Load(),
+		L2Hits:        t.l2Hits.Load(),
+		Misses:        t.misses.Load(),
+		L1Entries:     t.l1.Len(),
+		L1Bytes:       t.l1.Bytes(),
+		Invalidations: t.invalidations.Load(),
+	}
+	if total := s.L1Hits + s.L2Hits + s.Misses; total > 0 {
+		s.L1HitRate = float64(s.L1Hits) / float64(total)
+		s.L2HitRate = float64(s.L2Hits) / float64(total)
+	}
+	s.L2Degraded = t.l2Degraded()
+	return s
+}
+
+// l2Degraded reports whether L2 is a Fallback serving from memory, Redis being unreachable
+func (t *Tiered) l2Degraded() bool {
+	f, ok := t.l2.(*Fallback)
+	return ok && f.Degraded()
+}
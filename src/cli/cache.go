@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"ani4s/src/config"
+	movies "ani4s/src/modules/movies/services"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const cacheUsage = `usage: ani4s cache <flush|stats>
+
+  flush --tag NAME   delete every payload indexed by the tag (e.g. movie_list)
+  stats              keys, live payloads and memory per tag`
+
+// Cache runs the `cache` subcommand
+func Cache(args []string) error {
+	if len(args) == 0 {
+		return errors.New(cacheUsage)
+	}
+
+	switch args[0] {
+	case "flush":
+		fs := flag.NewFlagSet("cache flush", flag.ContinueOnError)
+		tag := fs.String("tag", "", "tag to flush: "+strings.Join(movies.CacheTags, ", "))
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *tag == "" {
+			return errors.New("--tag is required")
+		}
+
+		config.ConnectRedis()
+		removed, err := movies.FlushTag(*tag)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("flushed %d keys from %s\n", removed, movies.TagKey(*tag))
+		return nil
+
+	case "stats":
+		config.ConnectRedis()
+		stats, err := movies.CacheTagStats()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tKEYS\tLIVE\tBYTES")
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", s.Tag, s.Keys, s.Live, s.Bytes)
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(cacheUsage)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command is one maintenance subcommand of the binary
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"migrate":        {"apply, roll back or list database migrations", Migrate},
+	"crawl":          {"mirror the phimapi catalog into Postgres (resumable)", Crawl},
+	"sync-images":    {"mirror movie thumbnails and posters into MinIO", SyncImages},
+	"cache":          {"inspect or flush tagged Redis caches (flush --tag, stats)", Cache},
+	"reindex-search": {"rebuild the cached search results from phimapi", ReindexSearch},
+	"recommend":      {"rebuild the similar titles index", Recommend},
+	"config":         {"print the effective configuration, secrets redacted", Config},
+}
+
+// ErrUnknownCommand is returned by Run for a name that is not a subcommand
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Run executes the subcommand name with its arguments
+func Run(name string, args []string) error {
+	if IsHelp(name) {
+		Usage()
+		return nil
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		Usage()
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, name)
+	}
+	return cmd.run(args)
+}
+
+// IsHelp reports whether name asks for the list of subcommands
+func IsHelp(name string) bool {
+	return name == "help" || name == "-h" || name == "--help"
+}
+
+// Usage prints the list of subcommands
+func Usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: ani4s [command] [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "  %-16s %s\n", "serve", "start the HTTP server (default)")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].summary)
+	}
+}
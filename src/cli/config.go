@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"ani4s/src/config"
+	"fmt"
+)
+
+// Config prints the settings the server would run with, after the config file and the
+// environment are applied; main has already validated them
+func Config(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: ani4s config")
+	}
+	fmt.Print(config.App.String())
+	return nil
+}
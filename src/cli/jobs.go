@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"ani4s/src/config"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/services"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Crawl runs the catalog crawler in the foreground; Ctrl-C stops it at a resumable checkpoint
+func Crawl(args []string) error {
+	opts := services.DefaultCrawlOptions()
+	fs := flag.NewFlagSet("crawl", flag.ContinueOnError)
+	fs.BoolVar(&opts.Fresh, "fresh", false, "discard checkpoints and start from page 1")
+	fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency, "parallel detail fetches")
+	rps := fs.Float64("rps", float64(time.Second)/float64(opts.Interval), "upstream requests per second")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *rps > 0 {
+		opts.Interval = time.Duration(float64(time.Second) / *rps)
+	}
+
+	config.ConnectDatabase()
+	config.ConnectRedis()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return services.RunJob(ctx, "crawl", func(ctx context.Context) error {
+		return services.RunCatalogCrawl(ctx, opts)
+	})
+}
+
+// SyncImages mirrors every stored thumbnail and poster into MinIO once
+func SyncImages(args []string) error {
+	fs := flag.NewFlagSet("sync-images", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config.ConnectDatabase()
+	config.ConnectRedis()
+	config.ConnectMinio()
+
+	return services.RunJob(context.Background(), "image_sync", services.FetchAndUpdateThumbnails)
+}
+
+// ReindexSearch refreshes every cached search result and prunes stale entries
+func ReindexSearch(args []string) error {
+	fs := flag.NewFlagSet("reindex-search", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config.ConnectDatabase()
+	config.ConnectRedis()
+
+	res, err := movies.RefreshTag(context.Background(), "movie_search")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("refreshed %d, dropped %d\n", res.Refreshed, res.Dropped)
+	return nil
+}
+
+// Recommend rebuilds the similar titles of every stored movie once
+func Recommend(args []string) error {
+	fs := flag.NewFlagSet("recommend", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config.ConnectDatabase()
+	config.ConnectRedis()
+
+	start := time.Now()
+	n, err := movies.RebuildRecommendations()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("indexed %d movies in %s\n", n, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"ani4s/src/config"
+	"ani4s/src/migrations"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: ani4s migrate <up|down|status> [-steps N]
+
+  up      apply pending migrations (all unless -steps is set)
+  down    roll back the latest migration (or -steps of them)
+  status  list migrations and when they were applied`
+
+// Migrate runs the `migrate` subcommand
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	steps := fs.Int("steps", 0, "number of migrations to apply or roll back")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	sqlDB, err := config.OpenDatabase().DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	switch args[0] {
+	case "up":
+		return migrations.Up(sqlDB, *steps)
+	case "down":
+		return migrations.Down(sqlDB, *steps)
+	case "status":
+		list, err := migrations.List(sqlDB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range list {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
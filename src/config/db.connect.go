@@ -1,85 +1,84 @@
 package config
 
 import (
-	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
+	"ani4s/src/migrations"
+	"ani4s/src/tracing"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-	"log"
-	"os"
 )
 
 var DB *gorm.DB
 
+var configLog = logger.Named("config")
+
 // ConnectDatabase initializes and migrates the database.
 func ConnectDatabase() *gorm.DB {
-	dbUser := os.Getenv("DB_USER")
-	dbPass := os.Getenv("DB_PASS")
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
+	OpenDatabase()
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
-		dbHost, dbPort, dbUser, dbPass, dbName)
+	// Perform database migrations
+	if err := runMigrations(DB); err != nil {
+		configLog.Fatal(Ctx, "migration failed", "error", err)
+	}
+
+	return DB
+}
+
+// OpenDatabase connects to PostgreSQL without running migrations.
+func OpenDatabase() *gorm.DB {
+	settings := App.Database
+	dbHost, dbName := settings.Host, settings.Name
+
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+		dbHost, settings.Port, settings.User, settings.Password, dbName)
 
 	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logger.NewGorm(),
 	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		configLog.Fatal(Ctx, "failed to connect to database", "host", dbHost, "db", dbName, "error", err)
+	}
+
+	if err := database.Use(metrics.GormPlugin{}); err != nil {
+		configLog.Error(Ctx, "failed to register database metrics", "error", err)
+	}
+	if err := database.Use(tracing.GormPlugin{}); err != nil {
+		configLog.Error(Ctx, "failed to register database tracing", "error", err)
 	}
 
 	DB = database
-	fmt.Println("Connected to PostgreSQL database!")
+	configLog.Info(Ctx, "connected to PostgreSQL", "host", dbHost, "db", dbName)
 
 	// Enable uuid on db
 	DB.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`)
-	// Perform database migrations
-	if err := runMigrations(DB); err != nil {
-		log.Fatalf("Migration failed: %v", err)
-	}
 
 	return DB
 }
 
-func CheckConnection() bool {
+// CloseDatabase closes the connection pool, waiting for the queries in flight
+func CloseDatabase() error {
 	if DB == nil {
-		return false
+		return nil
 	}
-
 	sqlDB, err := DB.DB()
 	if err != nil {
-		log.Printf("Failed to get generic database object: %v", err)
-		return false
+		return err
 	}
-
-	if err := sqlDB.Ping(); err != nil {
-		log.Printf("Database ping failed: %v", err)
-		return false
-	}
-
-	var result int
-	if err := DB.Raw("SELECT 1").Scan(&result).Error; err != nil {
-		log.Printf("Test query failed: %v", err)
-		return false
-	}
-	return result == 1
+	return sqlDB.Close()
 }
 
 func runMigrations(db *gorm.DB) error {
-	migrations := []func(*gorm.DB) error{
-		movies.MigrateMovies,
-		movies.MigrateMovieDetails,
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
-
-	// Iterate through all migrations
-	for _, migrate := range migrations {
-		if err := migrate(db); err != nil {
-			return fmt.Errorf("migration failed: %w", err)
-		}
+	// Versioned SQL scripts, guarded by an advisory lock so only one replica migrates
+	if err := migrations.Up(sqlDB, 0); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
 	}
 
-	fmt.Println("All migrations completed successfully!")
+	configLog.Info(Ctx, "migrations completed")
 	return nil
 }
@@ -1,9 +1,8 @@
 package config
 
 import (
-	"fmt"
-	"log"
-	"os"
+	"context"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -15,34 +14,30 @@ var (
 )
 
 func ConnectMinio() *minio.Client {
-	// Đọc biến môi trường, hoặc hardcode cho local dev
-	endpoint := os.Getenv("MINIO_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "minio:9000"
-	}
-	accessKeyID := os.Getenv("MINIO_ACCESS_KEY")
-	if accessKeyID == "" {
-		accessKeyID = "admin"
-	}
-	secretAccessKey := os.Getenv("MINIO_SECRET_KEY")
-	if secretAccessKey == "" {
-		secretAccessKey = "password"
-	}
-	bucketName := os.Getenv("MINIO_BUCKET_NAME")
-	if bucketName == "" {
-		bucketName = "local"
-	}
-	useSSL := false
+	settings := App.Minio
+	endpoint, bucketName := settings.Endpoint, settings.Bucket
 
 	client, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
-		Secure: useSSL,
+		Creds:  credentials.NewStaticV4(settings.AccessKey, settings.SecretKey, ""),
+		Secure: settings.UseSSL,
 	})
 	if err != nil {
-		log.Fatalf("Cannot connect to MinIO: %v", err)
+		configLog.Fatal(Ctx, "cannot connect to MinIO", "endpoint", endpoint, "error", err)
 	}
-	fmt.Println("MinIO connected:", endpoint)
 	MinioClient = client
 	BucketName = bucketName
+
+	// minio.New does not dial: check the bucket once so a bad endpoint or credentials show at startup
+	ctx, cancel := context.WithTimeout(Ctx, 5*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, bucketName)
+	switch {
+	case err != nil:
+		configLog.Error(Ctx, "MinIO unreachable, images are served from Redis only", "endpoint", endpoint, "error", err)
+	case !exists:
+		configLog.Warn(Ctx, "MinIO bucket does not exist yet, it is created on the first upload", "endpoint", endpoint, "bucket", bucketName)
+	default:
+		configLog.Info(Ctx, "connected to MinIO", "endpoint", endpoint, "bucket", bucketName)
+	}
 	return MinioClient
 }
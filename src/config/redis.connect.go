@@ -1,61 +1,135 @@
 package config
 
 import (
+	"ani4s/src/cache"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
-	_ "time"
+	"strings"
 
 	"github.com/redis/go-redis/v9"
 )
 
 var (
-	RDB *redis.Client
-	Ctx = context.Background()
+	// RDB is a standalone, sentinel-backed or cluster client depending on redis.mode
+	RDB redis.UniversalClient
+	// CacheCodec serializes and compresses the payloads written to Cache
+	CacheCodec = cache.DefaultCodec
+	// Cache is the payload cache: an in-process L1 in front of Redis, or of an in-process
+	// LRU while Redis is unreachable
+	Cache cache.Cache
+	Ctx   = context.Background()
+
+	// stopCacheListener ends the invalidation subscription before the client is closed
+	stopCacheListener = func() {}
 )
 
-func ConnectRedis() (*redis.Client, context.Context) {
-	mode := os.Getenv("REDIS_MODE")
-
-	if mode == "sentinel" {
-		// Redis Sentinel Mode
-		masterName := os.Getenv("REDIS_MASTER_NAME")
-		password := os.Getenv("REDIS_PASSWORD")
-		sentinels := []string{
-			"redis-sentinel-node-0.redis-sentinel-headless.architecture.svc.cluster.local:26379",
-			"redis-sentinel-node-1.redis-sentinel-headless.architecture.svc.cluster.local:26379",
-			"redis-sentinel-node-2.redis-sentinel-headless.architecture.svc.cluster.local:26379",
-			"redis-sentinel-node-3.redis-sentinel-headless.architecture.svc.cluster.local:26379",
-			"redis-sentinel-node-4.redis-sentinel-headless.architecture.svc.cluster.local:26379",
-		}
+func ConnectRedis() (redis.UniversalClient, context.Context) {
+	settings := App.Redis
+	mode := strings.ToLower(settings.Mode)
 
-		RDB = redis.NewFailoverClient(&redis.FailoverOptions{
-			MasterName:       masterName,
-			SentinelAddrs:    sentinels,
-			Password:         password,
-			SentinelPassword: password,
-			DB:               0,
-		})
-	} else {
-		// Standalone Redis Mode (local dev)
-		host := os.Getenv("REDIS_HOST")
-		port := os.Getenv("REDIS_PORT")
-		password := os.Getenv("REDIS_PASSWORD")
-		addr := fmt.Sprintf("%s:%s", host, port)
+	opts, err := redisOptions(settings)
+	if err != nil {
+		configLog.Fatal(Ctx, "invalid Redis TLS settings", "error", err)
+	}
+	RDB = redis.NewUniversalClient(opts)
 
-		RDB = redis.NewClient(&redis.Options{
-			Addr:     addr,
-			Password: password,
-			DB:       0,
-		})
+	// The client is kept when Redis is unreachable: Cache serves from memory until Redis
+	// answers again and /readyz reports the service as degraded
+	cacheSettings := App.Cache
+	if codec, err := cache.ParseCodec(cacheSettings.Encoding, cacheSettings.Compression); err != nil {
+		configLog.Error(Ctx, "invalid cache codec, using the default", "error", err)
+	} else {
+		CacheCodec = codec
 	}
 
+	l1, fallback := cacheSettings.L1, cacheSettings.Fallback
+	l2 := cache.NewFallback(RDB, cache.NewMemory(fallback.Entries, fallback.MaxBytes))
+	tiered := cache.NewTiered(cache.NewMemory(l1.Entries, l1.MaxBytes), l2, l1.TTL, l1.MaxItem, RDB)
+	listenCtx, cancel := context.WithCancel(Ctx)
+	stopCacheListener = cancel
+	go tiered.Listen(listenCtx)
+	Cache = tiered
 	pong, err := RDB.Ping(Ctx).Result()
 	if err != nil {
-		fmt.Printf("Failed to connect to Redis (%s mode): %v\n", mode, err)
-		return nil, nil
+		configLog.Error(Ctx, "Redis unreachable, serving from the in-memory fallback until it comes back", "mode", mode, "addrs", opts.Addrs, "error", err)
+		return RDB, Ctx
 	}
 
-	fmt.Println("Redis connected (mode:", mode, "):", pong)
+	configLog.Info(Ctx, "connected to Redis", "mode", mode, "addrs", opts.Addrs, "tls", opts.TLSConfig != nil, "ping", pong)
 	return RDB, Ctx
 }
+
+// redisOptions maps the settings of a mode to the universal options: MasterName selects
+// a sentinel-backed client, IsClusterMode a cluster client, and a single address the
+// standalone one
+func redisOptions(settings RedisSettings) (*redis.UniversalOptions, error) {
+	opts := &redis.UniversalOptions{
+		Username: settings.Username,
+		Password: settings.Password,
+		DB:       settings.DB,
+	}
+	switch strings.ToLower(settings.Mode) {
+	case "sentinel":
+		opts.Addrs = settings.SentinelAddrs
+		opts.MasterName = settings.MasterName
+		opts.SentinelUsername = settings.SentinelUsername
+		opts.SentinelPassword = settings.SentinelPassword
+		if opts.SentinelPassword == "" {
+			opts.SentinelPassword = settings.Password
+		}
+	case "cluster":
+		opts.Addrs = settings.ClusterAddrs
+		opts.IsClusterMode = true
+	default:
+		opts.Addrs = []string{fmt.Sprintf("%s:%d", settings.Host, settings.Port)}
+	}
+
+	if settings.TLS.Enabled {
+		tlsConfig, err := redisTLSConfig(settings.TLS)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsConfig
+	}
+	return opts, nil
+}
+
+func redisTLSConfig(settings RedisTLSSettings) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in CA file " + settings.CAFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if settings.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// CloseRedis stops the cache invalidation listener and closes the Redis client
+func CloseRedis() error {
+	stopCacheListener()
+	if RDB == nil {
+		return nil
+	}
+	return RDB.Close()
+}
@@ -0,0 +1,447 @@
+package config
+
+import (
+	"ani4s/src/cache"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the optional YAML file read by Load
+const FileEnv = "CONFIG_FILE"
+
+const redacted = "********"
+
+// Settings is the whole configuration of the service. Load builds it from the defaults,
+// then the YAML file named by CONFIG_FILE, then the environment, which wins. Empty
+// variables count as unset. Fields tagged secret are masked by Redacted. `ani4s config`
+// prints the effective settings in the format of the file.
+type Settings struct {
+	Env      string           `yaml:"env" env:"NODE_ENV"`
+	Server   ServerSettings   `yaml:"server"`
+	Log      LogSettings      `yaml:"log"`
+	Tracing  TracingSettings  `yaml:"tracing"`
+	Database DatabaseSettings `yaml:"database"`
+	Redis    RedisSettings    `yaml:"redis"`
+	Minio    MinioSettings    `yaml:"minio"`
+	Cache    CacheSettings    `yaml:"cache"`
+	Jobs     JobSettings      `yaml:"jobs"`
+	Crawler  CrawlerSettings  `yaml:"crawler"`
+
+	// File is the YAML file the settings were read from, if any
+	File string `yaml:"-"`
+}
+
+type ServerSettings struct {
+	Host string `yaml:"host" env:"HOST"`
+	Port int    `yaml:"port" env:"APP_PORT"`
+	// ShutdownTimeout bounds the drain of requests and jobs on SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// AdminToken protects /api/v1/admin; the admin API is disabled without it
+	AdminToken string `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+	// UserTokenSecret verifies the user tokens issued by the login service; without it
+	// every request is anonymous
+	UserTokenSecret string `yaml:"user_token_secret" env:"USER_TOKEN_SECRET" secret:"true"`
+}
+
+type LogSettings struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn or error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json or text
+}
+
+type TracingSettings struct {
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"` // none, otlp or stdout
+}
+
+type DatabaseSettings struct {
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASS" secret:"true"`
+	Name     string `yaml:"name" env:"DB_NAME"`
+}
+
+type RedisSettings struct {
+	Mode     string `yaml:"mode" env:"REDIS_MODE"` // standalone, sentinel or cluster
+	Host     string `yaml:"host" env:"REDIS_HOST"` // standalone only
+	Port     int    `yaml:"port" env:"REDIS_PORT"`
+	Username string `yaml:"username" env:"REDIS_USERNAME"` // ACL user, empty for the default user
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"` // always 0 on a cluster
+	// MasterName and SentinelAddrs (host:port, comma separated in the environment) are used
+	// in sentinel mode. The sentinels share the password of the data nodes unless
+	// SentinelPassword is set.
+	MasterName       string   `yaml:"master_name" env:"REDIS_MASTER_NAME"`
+	SentinelAddrs    []string `yaml:"sentinel_addrs" env:"REDIS_SENTINEL_ADDRS"`
+	SentinelUsername string   `yaml:"sentinel_username" env:"REDIS_SENTINEL_USERNAME"`
+	SentinelPassword string   `yaml:"sentinel_password" env:"REDIS_SENTINEL_PASSWORD" secret:"true"`
+	// ClusterAddrs are seed nodes of a Redis Cluster; the others are discovered. One
+	// configuration endpoint (ElastiCache, Memorystore) is enough.
+	ClusterAddrs []string         `yaml:"cluster_addrs" env:"REDIS_CLUSTER_ADDRS"`
+	TLS          RedisTLSSettings `yaml:"tls"`
+}
+
+// RedisTLSSettings encrypts the connections to every node (and sentinel). The system roots
+// are trusted unless CAFile is set; CertFile and KeyFile authenticate the client.
+type RedisTLSSettings struct {
+	Enabled            bool   `yaml:"enabled" env:"REDIS_TLS"`
+	CAFile             string `yaml:"ca_file" env:"REDIS_TLS_CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"REDIS_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"REDIS_TLS_KEY_FILE"`
+	ServerName         string `yaml:"server_name" env:"REDIS_TLS_SERVER_NAME"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"REDIS_TLS_INSECURE_SKIP_VERIFY"`
+}
+
+type MinioSettings struct {
+	Endpoint  string `yaml:"endpoint" env:"MINIO_ENDPOINT"`
+	AccessKey string `yaml:"access_key" env:"MINIO_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"MINIO_SECRET_KEY" secret:"true"`
+	Bucket    string `yaml:"bucket" env:"MINIO_BUCKET_NAME"`
+	UseSSL    bool   `yaml:"use_ssl" env:"MINIO_USE_SSL"`
+}
+
+type CacheSettings struct {
+	Encoding    string `yaml:"encoding" env:"CACHE_ENCODING"`       // json or msgpack
+	Compression string `yaml:"compression" env:"CACHE_COMPRESSION"` // none, snappy or zstd
+	// L1 holds the hot payloads of this replica for a few seconds in front of Redis;
+	// payloads larger than MaxItem (images, long series) stay in Redis only
+	L1 struct {
+		Entries  int           `yaml:"entries" env:"CACHE_L1_ENTRIES"`
+		MaxBytes int64         `yaml:"max_bytes" env:"CACHE_L1_MAX_BYTES"`
+		MaxItem  int           `yaml:"max_item" env:"CACHE_L1_MAX_ITEM"`
+		TTL      time.Duration `yaml:"ttl" env:"CACHE_L1_TTL"`
+	} `yaml:"l1"`
+	// Fallback only holds what is cached during a Redis outage
+	Fallback struct {
+		Entries  int   `yaml:"entries" env:"CACHE_FALLBACK_ENTRIES"`
+		MaxBytes int64 `yaml:"max_bytes" env:"CACHE_FALLBACK_MAX_BYTES"`
+	} `yaml:"fallback"`
+	TTL CacheTTLs `yaml:"ttl"`
+}
+
+// CacheTTLs are the lifetimes of the cached payloads. Search results change quickly and
+// get a shorter TTL than lists.
+type CacheTTLs struct {
+	List     time.Duration `yaml:"list" env:"CACHE_TTL_LIST"`
+	Search   time.Duration `yaml:"search" env:"CACHE_TTL_SEARCH"`
+	Category time.Duration `yaml:"category" env:"CACHE_TTL_CATEGORY"`
+	Country  time.Duration `yaml:"country" env:"CACHE_TTL_COUNTRY"`
+	Newest   time.Duration `yaml:"newest" env:"CACHE_TTL_NEWEST"`
+	Details  time.Duration `yaml:"details" env:"CACHE_TTL_DETAILS"`
+	Taxonomy time.Duration `yaml:"taxonomy" env:"CACHE_TTL_TAXONOMY"` // category and country lists
+	Home     time.Duration `yaml:"home" env:"CACHE_TTL_HOME"`
+	// Image is the TTL of a downloaded image, ImageStored of one read back from MinIO
+	Image       time.Duration `yaml:"image" env:"CACHE_TTL_IMAGE"`
+	ImageStored time.Duration `yaml:"image_stored" env:"CACHE_TTL_IMAGE_STORED"`
+}
+
+// JobSettings schedules the background jobs. Trending and similar lists are cached
+// until their next rebuild.
+type JobSettings struct {
+	SyncInterval      time.Duration `yaml:"sync_interval" env:"JOB_SYNC_INTERVAL"`
+	ImageSyncInterval time.Duration `yaml:"image_sync_interval" env:"JOB_IMAGE_SYNC_INTERVAL"`
+	TrendingInterval  time.Duration `yaml:"trending_interval" env:"JOB_TRENDING_INTERVAL"`
+	RecommendInterval time.Duration `yaml:"recommend_interval" env:"JOB_RECOMMEND_INTERVAL"`
+	CrawlSchedule     string        `yaml:"crawl_schedule" env:"JOB_CRAWL_SCHEDULE"` // cron spec
+}
+
+type CrawlerSettings struct {
+	Concurrency int     `yaml:"concurrency" env:"CRAWLER_CONCURRENCY"`
+	RPS         float64 `yaml:"rps" env:"CRAWLER_RPS"`
+}
+
+// App is the configuration in use. It holds the defaults until Load runs, so packages
+// used without main (the CLI, tools) still see sane values.
+var App = Defaults()
+
+// Defaults returns the configuration of a local setup
+func Defaults() *Settings {
+	s := &Settings{
+		Env:      "development",
+		Server:   ServerSettings{Host: "0.0.0.0", Port: 2000, ShutdownTimeout: 30 * time.Second},
+		Log:      LogSettings{Level: "info", Format: "json"},
+		Tracing:  TracingSettings{Exporter: "none"},
+		Database: DatabaseSettings{Host: "localhost", Port: 5432},
+		Redis:    RedisSettings{Mode: "standalone", Host: "localhost", Port: 6379},
+		Minio:    MinioSettings{Endpoint: "minio:9000", AccessKey: "admin", SecretKey: "password", Bucket: "local"},
+		Cache: CacheSettings{
+			Encoding:    "msgpack",
+			Compression: "zstd",
+			TTL: CacheTTLs{
+				List:        16 * time.Hour,
+				Search:      8 * time.Hour,
+				Category:    16 * time.Hour,
+				Country:     16 * time.Hour,
+				Newest:      16 * time.Hour,
+				Details:     16 * time.Hour,
+				Taxonomy:    23 * time.Hour,
+				Home:        10 * time.Minute,
+				Image:       24 * time.Hour,
+				ImageStored: 6 * time.Hour,
+			},
+		},
+		Jobs: JobSettings{
+			SyncInterval:      15 * time.Minute,
+			ImageSyncInterval: 10 * time.Minute,
+			TrendingInterval:  10 * time.Minute,
+			RecommendInterval: 6 * time.Hour,
+			CrawlSchedule:     "@weekly",
+		},
+		Crawler: CrawlerSettings{Concurrency: 4, RPS: 5},
+	}
+	s.Cache.L1.Entries = 5000
+	s.Cache.L1.MaxBytes = 64 << 20
+	s.Cache.L1.MaxItem = 256 << 10
+	s.Cache.L1.TTL = 30 * time.Second
+	s.Cache.Fallback.Entries = 10000
+	s.Cache.Fallback.MaxBytes = 64 << 20
+	return s
+}
+
+// Load reads the configuration into App and validates it. App is set even when the
+// settings are invalid, so the health check probe still finds the port.
+func Load() (*Settings, error) {
+	s := Defaults()
+	var errs []error
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := s.readFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(s).Elem()); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	App = s
+	return s, errors.Join(errs...)
+}
+
+// readFile decodes a YAML file over s; unknown keys are errors so typos do not go unnoticed
+func (s *Settings) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	s.File = path
+	return nil
+}
+
+// applyEnv overrides the fields of v that have an env tag with the variables that are set
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw := strings.TrimSpace(os.Getenv(name))
+		if raw == "" {
+			continue
+		}
+		if err := setField(value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", name, raw, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setField(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("not a duration (e.g. 30s, 15m, 6h)")
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("not a boolean")
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("not a number")
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once
+func (s *Settings) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	positive := func(d time.Duration, name, env string) {
+		check(d > 0, "%s (%s) must be a positive duration, got %s", name, env, d)
+	}
+	validPort := func(port int, name, env string) {
+		check(port > 0 && port < 65536, "%s (%s) must be between 1 and 65535, got %d", name, env, port)
+	}
+
+	validPort(s.Server.Port, "server.port", "APP_PORT")
+	positive(s.Server.ShutdownTimeout, "server.shutdown_timeout", "SHUTDOWN_TIMEOUT")
+	check(oneOf(s.Log.Level, "debug", "info", "warn", "warning", "error"),
+		"log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", s.Log.Level)
+	check(oneOf(s.Log.Format, "json", "text"), "log.format (LOG_FORMAT) must be json or text, got %q", s.Log.Format)
+	check(oneOf(s.Tracing.Exporter, "", "none", "otlp", "stdout"),
+		"tracing.exporter (OTEL_TRACES_EXPORTER) must be none, otlp or stdout, got %q", s.Tracing.Exporter)
+
+	check(s.Database.Host != "", "database.host (DB_HOST) is required")
+	validPort(s.Database.Port, "database.port", "DB_PORT")
+	check(s.Database.User != "", "database.user (DB_USER) is required")
+	check(s.Database.Name != "", "database.name (DB_NAME) is required")
+
+	switch strings.ToLower(s.Redis.Mode) {
+	case "standalone":
+		check(s.Redis.Host != "", "redis.host (REDIS_HOST) is required")
+		validPort(s.Redis.Port, "redis.port", "REDIS_PORT")
+	case "sentinel":
+		check(s.Redis.MasterName != "", "redis.master_name (REDIS_MASTER_NAME) is required in sentinel mode")
+		check(len(s.Redis.SentinelAddrs) > 0, "redis.sentinel_addrs (REDIS_SENTINEL_ADDRS) is required in sentinel mode")
+	case "cluster":
+		check(len(s.Redis.ClusterAddrs) > 0, "redis.cluster_addrs (REDIS_CLUSTER_ADDRS) is required in cluster mode")
+		check(s.Redis.DB == 0, "redis.db (REDIS_DB) must be 0 in cluster mode, got %d", s.Redis.DB)
+	default:
+		check(false, "redis.mode (REDIS_MODE) must be standalone, sentinel or cluster, got %q", s.Redis.Mode)
+	}
+	check(s.Redis.DB >= 0, "redis.db (REDIS_DB) must not be negative, got %d", s.Redis.DB)
+	if tlsSettings := s.Redis.TLS; tlsSettings.Enabled {
+		check((tlsSettings.CertFile == "") == (tlsSettings.KeyFile == ""),
+			"redis.tls cert_file (REDIS_TLS_CERT_FILE) and key_file (REDIS_TLS_KEY_FILE) go together")
+		for _, file := range []struct{ path, name, env string }{
+			{tlsSettings.CAFile, "redis.tls.ca_file", "REDIS_TLS_CA_FILE"},
+			{tlsSettings.CertFile, "redis.tls.cert_file", "REDIS_TLS_CERT_FILE"},
+			{tlsSettings.KeyFile, "redis.tls.key_file", "REDIS_TLS_KEY_FILE"},
+		} {
+			if file.path != "" {
+				_, err := os.Stat(file.path)
+				check(err == nil, "%s (%s): %v", file.name, file.env, err)
+			}
+		}
+	}
+
+	check(s.Minio.Endpoint != "", "minio.endpoint (MINIO_ENDPOINT) is required")
+	check(s.Minio.Bucket != "", "minio.bucket (MINIO_BUCKET_NAME) is required")
+
+	_, err := cache.ParseCodec(s.Cache.Encoding, s.Cache.Compression)
+	check(err == nil, "cache codec (CACHE_ENCODING, CACHE_COMPRESSION): %v", err)
+	check(s.Cache.L1.Entries > 0 && s.Cache.L1.MaxBytes > 0 && s.Cache.L1.MaxItem > 0,
+		"cache.l1 entries, max_bytes and max_item must be positive")
+	positive(s.Cache.L1.TTL, "cache.l1.ttl", "CACHE_L1_TTL")
+	check(s.Cache.Fallback.Entries > 0 && s.Cache.Fallback.MaxBytes > 0,
+		"cache.fallback entries and max_bytes must be positive")
+	ttls := reflect.ValueOf(s.Cache.TTL)
+	for i := 0; i < ttls.NumField(); i++ {
+		field := ttls.Type().Field(i)
+		positive(time.Duration(ttls.Field(i).Int()), "cache.ttl."+field.Tag.Get("yaml"), field.Tag.Get("env"))
+	}
+
+	// cron rounds @every intervals to the second
+	for _, job := range []struct {
+		every     time.Duration
+		name, env string
+	}{
+		{s.Jobs.SyncInterval, "jobs.sync_interval", "JOB_SYNC_INTERVAL"},
+		{s.Jobs.ImageSyncInterval, "jobs.image_sync_interval", "JOB_IMAGE_SYNC_INTERVAL"},
+		{s.Jobs.TrendingInterval, "jobs.trending_interval", "JOB_TRENDING_INTERVAL"},
+		{s.Jobs.RecommendInterval, "jobs.recommend_interval", "JOB_RECOMMEND_INTERVAL"},
+	} {
+		check(job.every >= time.Second, "%s (%s) must be at least 1s, got %s", job.name, job.env, job.every)
+	}
+	_, err = cron.ParseStandard(s.Jobs.CrawlSchedule)
+	check(err == nil, "jobs.crawl_schedule (JOB_CRAWL_SCHEDULE) is not a cron spec: %v", err)
+
+	check(s.Crawler.Concurrency > 0, "crawler.concurrency (CRAWLER_CONCURRENCY) must be positive, got %d", s.Crawler.Concurrency)
+	check(s.Crawler.RPS > 0, "crawler.rps (CRAWLER_RPS) must be positive, got %g", s.Crawler.RPS)
+
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of s with the secrets masked, safe to print or log
+func (s *Settings) Redacted() *Settings {
+	c := *s
+	redact(reflect.ValueOf(&c).Elem())
+	return &c
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			redact(value)
+		case field.Tag.Get("secret") == "true" && value.Kind() == reflect.String && value.String() != "":
+			value.SetString(redacted)
+		}
+	}
+}
+
+// String renders the settings as YAML with the secrets masked
+func (s *Settings) String() string {
+	out, err := yaml.Marshal(s.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every variable read by Load, so the host environment does not leak in
+func clearEnv(t *testing.T, v reflect.Value) {
+	t.Helper()
+	ty := v.Type()
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			clearEnv(t, v.Field(i))
+			continue
+		}
+		if name := field.Tag.Get("env"); name != "" {
+			t.Setenv(name, "")
+		}
+	}
+	t.Setenv(FileEnv, "")
+}
+
+// load runs Load with only the given file and variables set
+func load(t *testing.T, file string, env map[string]string) (*Settings, error) {
+	t.Helper()
+	clearEnv(t, reflect.ValueOf(Settings{}))
+	if file != "" {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(FileEnv, path)
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	previous := App
+	t.Cleanup(func() { App = previous })
+	return Load()
+}
+
+// required holds the settings that have no default
+var required = map[string]string{"DB_USER": "ani4s", "DB_NAME": "ani4s"}
+
+func with(env map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range required {
+		out[k] = v
+	}
+	for k, v := range env {
+		out[k] = v
+	}
+	return out
+}
+
+func TestDefaults(t *testing.T) {
+	s, err := load(t, "", required)
+	if err != nil {
+		t.Fatalf("defaults with a database user and name are invalid: %v", err)
+	}
+	if App != s {
+		t.Error("Load did not set App")
+	}
+
+	want := Defaults()
+	want.Database.User, want.Database.Name = "ani4s", "ani4s"
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("Load() = %+v, want the defaults %+v", s, want)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := `
+server:
+  port: 3000
+redis:
+  mode: cluster
+  cluster_addrs: [a:7000, b:7000]
+cache:
+  ttl:
+    home: 2h
+jobs:
+  crawl_schedule: "0 3 * * *"
+crawler:
+  rps: 2.5
+`
+	tests := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(s *Settings) bool
+	}{
+		{"default port", "", nil, func(s *Settings) bool { return s.Server.Port == 2000 }},
+		{"file over default", file, nil, func(s *Settings) bool { return s.Server.Port == 3000 }},
+		{"env over file", file, map[string]string{"APP_PORT": "4000"}, func(s *Settings) bool { return s.Server.Port == 4000 }},
+		{"empty env is unset", file, map[string]string{"APP_PORT": " "}, func(s *Settings) bool { return s.Server.Port == 3000 }},
+		{"file keeps other defaults", file, nil, func(s *Settings) bool {
+			return s.Server.Host == "0.0.0.0" && s.Cache.TTL.List == 16*time.Hour
+		}},
+		{"file duration", file, nil, func(s *Settings) bool { return s.Cache.TTL.Home == 2*time.Hour }},
+		{"env duration", file, map[string]string{"CACHE_TTL_HOME": "90m"}, func(s *Settings) bool {
+			return s.Cache.TTL.Home == 90*time.Minute
+		}},
+		{"file list", file, nil, func(s *Settings) bool {
+			return reflect.DeepEqual(s.Redis.ClusterAddrs, []string{"a:7000", "b:7000"})
+		}},
+		{"env list", file, map[string]string{"REDIS_CLUSTER_ADDRS": "c:7000, d:7000,"}, func(s *Settings) bool {
+			return reflect.DeepEqual(s.Redis.ClusterAddrs, []string{"c:7000", "d:7000"})
+		}},
+		{"env float and bool", "", map[string]string{"CRAWLER_RPS": "0.5", "MINIO_USE_SSL": "true"}, func(s *Settings) bool {
+			return s.Crawler.RPS == 0.5 && s.Minio.UseSSL
+		}},
+		{"file name recorded", file, nil, func(s *Settings) bool { return s.File != "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := load(t, tt.file, with(tt.env))
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if !tt.check(s) {
+				t.Errorf("unexpected settings:\n%s", s)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want []string
+	}{
+		{"missing required", "", map[string]string{"DB_USER": "", "DB_NAME": ""}, []string{"database.user (DB_USER)", "database.name (DB_NAME)"}},
+		{"unknown file key", "server:\n  prot: 3000\n", nil, []string{"invalid config file", "prot"}},
+		{"bad integer", "", map[string]string{"APP_PORT": "http"}, []string{`APP_PORT="http": not an integer`}},
+		{"bad duration", "", map[string]string{"CACHE_TTL_LIST": "16"}, []string{"CACHE_TTL_LIST", "not a duration"}},
+		{"bad boolean", "", map[string]string{"REDIS_TLS": "maybe"}, []string{"REDIS_TLS", "not a boolean"}},
+		{"port range", "", map[string]string{"APP_PORT": "70000"}, []string{"server.port (APP_PORT) must be between 1 and 65535"}},
+		{"log level", "", map[string]string{"LOG_LEVEL": "loud"}, []string{"log.level (LOG_LEVEL)"}},
+		{"redis mode", "", map[string]string{"REDIS_MODE": "ring"}, []string{"redis.mode (REDIS_MODE)"}},
+		{"sentinel without master", "", map[string]string{"REDIS_MODE": "sentinel"}, []string{"REDIS_MASTER_NAME", "REDIS_SENTINEL_ADDRS"}},
+		{"cluster db", "", map[string]string{"REDIS_MODE": "cluster", "REDIS_CLUSTER_ADDRS": "a:7000", "REDIS_DB": "1"}, []string{"must be 0 in cluster mode"}},
+		{"tls cert without key", "", map[string]string{"REDIS_TLS": "true", "REDIS_TLS_CERT_FILE": "/nonexistent.pem"}, []string{"go together", "redis.tls.cert_file"}},
+		{"cache codec", "", map[string]string{"CACHE_COMPRESSION": "lz4"}, []string{"cache codec"}},
+		{"ttl", "", map[string]string{"CACHE_TTL_HOME": "0s"}, []string{"cache.ttl.home (CACHE_TTL_HOME)"}},
+		{"job interval", "", map[string]string{"JOB_TRENDING_INTERVAL": "500ms"}, []string{"jobs.trending_interval (JOB_TRENDING_INTERVAL) must be at least 1s"}},
+		{"crawl schedule", "", map[string]string{"JOB_CRAWL_SCHEDULE": "sometimes"}, []string{"jobs.crawl_schedule"}},
+		{"crawler", "", map[string]string{"CRAWLER_CONCURRENCY": "0", "CRAWLER_RPS": "-1"}, []string{"CRAWLER_CONCURRENCY", "CRAWLER_RPS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := load(t, tt.file, with(tt.env))
+			if err == nil {
+				t.Fatal("Load() succeeded, want an error")
+			}
+			if App != s {
+				t.Error("App is not set when the settings are invalid")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	s := Defaults()
+	s.Database.Password = "db-secret"
+	s.Redis.Password = ""
+	s.Server.AdminToken = "admin-secret"
+
+	r := s.Redacted()
+	if r.Database.Password != redacted || r.Server.AdminToken != redacted || r.Minio.SecretKey != redacted {
+		t.Errorf("secrets are not masked: %+v", r)
+	}
+	if r.Redis.Password != "" {
+		t.Errorf("empty secret masked as %q", r.Redis.Password)
+	}
+	if s.Database.Password != "db-secret" {
+		t.Error("Redacted changed the original settings")
+	}
+	if out := s.String(); strings.Contains(out, "db-secret") || strings.Contains(out, "admin-secret") {
+		t.Errorf("String() leaks a secret:\n%s", out)
+	}
+}
@@ -0,0 +1,94 @@
+package health
+
+import (
+	"ani4s/src/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// upstreamURL is a small phimapi document, enough to tell whether the API answers
+const upstreamURL = "https://phimapi.com/the-loai"
+
+var errNotConnected = errors.New("not connected")
+
+var checkers = []*checker{
+	{
+		name:     "postgres",
+		critical: true,
+		impact:   "movie details, collections, trending and admin writes fail",
+		timeout:  2 * time.Second,
+		run:      checkPostgres,
+	},
+	{
+		name:    "redis",
+		impact:  "no shared cache: each replica caches in process, misses go to Postgres and phimapi",
+		timeout: 2 * time.Second,
+		run:     checkRedis,
+	},
+	{
+		name:    "minio",
+		impact:  "images that are not cached in Redis cannot be served",
+		timeout: 3 * time.Second,
+		run:     checkMinio,
+	},
+	{
+		name:    "upstream",
+		impact:  "serving from cache: uncached listings and new titles are unavailable",
+		timeout: 5 * time.Second,
+		every:   30 * time.Second,
+		run:     checkUpstream,
+	},
+}
+
+func checkPostgres(ctx context.Context) error {
+	if config.DB == nil {
+		return errNotConnected
+	}
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func checkRedis(ctx context.Context) error {
+	if config.RDB == nil {
+		return errNotConnected
+	}
+	return config.RDB.Ping(ctx).Err()
+}
+
+func checkMinio(ctx context.Context) error {
+	if config.MinioClient == nil {
+		return errNotConnected
+	}
+	exists, err := config.MinioClient.BucketExists(ctx, config.BucketName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", config.BucketName)
+	}
+	return nil
+}
+
+func checkUpstream(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstreamURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("phimapi returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"ani4s/src/metrics"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is the state of a dependency or of the whole service
+type Status string
+
+const (
+	StatusUp Status = "up"
+	// StatusDegraded: a non-critical dependency is down, requests are still served
+	// (from cache when phimapi is unreachable, from phimapi when Redis is)
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Result is the outcome of the last check of one dependency
+type Result struct {
+	Name      string    `json:"name"`
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	Impact    string    `json:"impact,omitempty"` // what stops working while it is down
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the readiness of the service and of each dependency
+type Report struct {
+	Status    Status   `json:"status"`
+	Checks    []Result `json:"checks"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+// checker probes one dependency. Results younger than every are reused, so probes
+// polling /readyz do not hit phimapi on every call.
+type checker struct {
+	name     string
+	critical bool
+	impact   string
+	timeout  time.Duration
+	every    time.Duration
+	run      func(ctx context.Context) error
+
+	mu   sync.Mutex
+	last *Result
+}
+
+func (c *checker) check(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.every {
+		return *c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runSafe(ctx, c.run)
+	res := Result{
+		Name:      c.name,
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+		res.Impact = c.impact
+	}
+	metrics.DependencyUp.WithLabelValues(c.name).Set(boolToFloat(err == nil))
+	c.last = &res
+	return res
+}
+
+// runSafe turns a panic of a check (an unset client...) into a failed check
+func runSafe(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("check panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// Check runs every dependency check concurrently. The service is down when a critical
+// dependency is down, degraded when any other one is.
+func Check(ctx context.Context) Report {
+	results := make([]Result, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c *checker) {
+			defer wg.Done()
+			results[i] = c.check(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results, Timestamp: time.Now().Unix()}
+	for _, r := range results {
+		if r.Status != StatusDown {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// HTTPStatus is the probe status code of a report: only a down service is taken out of rotation
+func (r Report) HTTPStatus() int {
+	if r.Status == StatusDown {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// Probe asks the server listening on addr whether it is alive; it backs `ani4s --health-check`
+func Probe(addr string) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + addr + "/healthz")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -3,28 +3,66 @@ package lib
 import (
 	"github.com/gorilla/websocket"
 	"sync"
+	"time"
 )
 
 var (
-	UserSocketMap = make(map[uint]*websocket.Conn)
+	// UserSocketMap holds every open connection of each user: one per tab or device
+	UserSocketMap = make(map[uint]map[*websocket.Conn]struct{})
 	MapMutex      = sync.RWMutex{} // processing concurrent map access
 )
 
-func SetUserSocket(userID uint, conn *websocket.Conn) {
+// AddUserSocket registers one more connection of userID
+func AddUserSocket(userID uint, conn *websocket.Conn) {
 	MapMutex.Lock()
 	defer MapMutex.Unlock()
-	UserSocketMap[userID] = conn
+	conns, ok := UserSocketMap[userID]
+	if !ok {
+		conns = make(map[*websocket.Conn]struct{})
+		UserSocketMap[userID] = conns
+	}
+	conns[conn] = struct{}{}
 }
 
-func GetUserSocket(userID uint) (*websocket.Conn, bool) {
+// GetUserSockets returns the open connections of userID
+func GetUserSockets(userID uint) []*websocket.Conn {
 	MapMutex.RLock()
 	defer MapMutex.RUnlock()
-	conn, exists := UserSocketMap[userID]
-	return conn, exists
+	out := make([]*websocket.Conn, 0, len(UserSocketMap[userID]))
+	for conn := range UserSocketMap[userID] {
+		out = append(out, conn)
+	}
+	return out
 }
 
-func RemoveUserSocket(userID uint) {
+// RemoveUserSocket forgets conn; the other connections of userID stay registered
+func RemoveUserSocket(userID uint, conn *websocket.Conn) {
 	MapMutex.Lock()
 	defer MapMutex.Unlock()
-	delete(UserSocketMap, userID)
+	conns := UserSocketMap[userID]
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(UserSocketMap, userID)
+	}
+}
+
+// CloseAllSockets sends a close frame with code and reason to every connection of every
+// user, then closes them; their handlers clean up when their read fails. It returns how
+// many connections were closed.
+func CloseAllSockets(code int, reason string) int {
+	MapMutex.RLock()
+	var conns []*websocket.Conn
+	for _, userConns := range UserSocketMap {
+		for conn := range userConns {
+			conns = append(conns, conn)
+		}
+	}
+	MapMutex.RUnlock()
+
+	frame := websocket.FormatCloseMessage(code, reason)
+	for _, conn := range conns {
+		_ = conn.WriteControl(websocket.CloseMessage, frame, time.Now().Add(time.Second))
+		_ = conn.Close()
+	}
+	return len(conns)
 }
@@ -0,0 +1,77 @@
+package lib
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialUser opens a connection registered under userID and returns the client side
+func dialUser(t *testing.T, userID uint) *websocket.Conn {
+	t.Helper()
+	registered := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		AddUserSocket(userID, conn)
+		registered <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	<-registered
+	return client
+}
+
+func TestCloseAllSocketsReachesEveryConnectionOfAUser(t *testing.T) {
+	t.Cleanup(func() { UserSocketMap = make(map[uint]map[*websocket.Conn]struct{}) })
+
+	tab := dialUser(t, 1)
+	phone := dialUser(t, 1)
+	other := dialUser(t, 2)
+	if n := len(GetUserSockets(1)); n != 2 {
+		t.Fatalf("user 1 has %d connections registered, want 2", n)
+	}
+
+	if n := CloseAllSockets(websocket.CloseServiceRestart, "server restarting"); n != 3 {
+		t.Errorf("CloseAllSockets closed %d connections, want 3", n)
+	}
+	for name, client := range map[string]*websocket.Conn{"tab": tab, "phone": phone, "other user": other} {
+		_ = client.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, err := client.ReadMessage()
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseServiceRestart {
+			t.Errorf("%s: read error = %v, want a %d close frame", name, err, websocket.CloseServiceRestart)
+		}
+	}
+}
+
+func TestRemoveUserSocketKeepsTheOtherConnections(t *testing.T) {
+	t.Cleanup(func() { UserSocketMap = make(map[uint]map[*websocket.Conn]struct{}) })
+
+	first, second := &websocket.Conn{}, &websocket.Conn{}
+	AddUserSocket(1, first)
+	AddUserSocket(1, second)
+
+	RemoveUserSocket(1, first)
+	if conns := GetUserSockets(1); len(conns) != 1 || conns[0] != second {
+		t.Errorf("after removing one connection: %v, want only the other one", conns)
+	}
+	RemoveUserSocket(1, second)
+	if _, ok := UserSocketMap[1]; ok {
+		t.Error("user without connections is still registered")
+	}
+}
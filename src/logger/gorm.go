@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQueryThreshold is the duration above which a query is logged at warn level
+const SlowQueryThreshold = 200 * time.Millisecond
+
+// Gorm adapts the process logger to GORM: failed and slow queries are always
+// logged, every other query only at debug level.
+type Gorm struct {
+	level gormlogger.LogLevel
+}
+
+// NewGorm returns the GORM logger
+func NewGorm() *Gorm {
+	return &Gorm{level: gormlogger.Info}
+}
+
+var gormLog = Named("gorm")
+
+func (g *Gorm) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &Gorm{level: level}
+}
+
+func (g *Gorm) Info(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Info {
+		gormLog.Info(ctx, msg, "args", args)
+	}
+}
+
+func (g *Gorm) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Warn {
+		gormLog.Warn(ctx, msg, "args", args)
+	}
+}
+
+func (g *Gorm) Error(ctx context.Context, msg string, args ...interface{}) {
+	if g.level >= gormlogger.Error {
+		gormLog.Error(ctx, msg, "args", args)
+	}
+}
+
+func (g *Gorm) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if g.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && g.level >= gormlogger.Error:
+		sql, rows := fc()
+		gormLog.Error(ctx, "query failed", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds(), "error", err)
+	case elapsed > SlowQueryThreshold && g.level >= gormlogger.Warn:
+		sql, rows := fc()
+		gormLog.Warn(ctx, "slow query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	case FromContext(ctx).Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		gormLog.Debug(ctx, "query", "sql", sql, "rows", rows, "duration_ms", elapsed.Milliseconds())
+	}
+}
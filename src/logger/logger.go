@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ctxKey stores the scoped *slog.Logger of a request, job run or socket connection
+type ctxKey struct{}
+
+// Init installs the process logger. level is debug, info, warn or error (default info);
+// format is json (default) or text. The standard log package is routed through it, so
+// leftover log.Printf calls come out in the same format at info level.
+func Init(level, format string) {
+	Setup(os.Stdout, level, format)
+}
+
+// Setup is Init writing to w
+func Setup(w io.Writer, level, format string) {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(0)
+}
+
+// ParseLevel maps a level name to a slog.Level, defaulting to info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewID returns a random identifier for a request, job run or connection
+func NewID() string {
+	return uuid.NewString()
+}
+
+// FromContext returns the scoped logger of ctx, or the process logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// With returns a copy of ctx whose logger carries args (key/value pairs) on every record
+func With(ctx context.Context, args ...interface{}) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, ctxKey{}, FromContext(ctx).With(args...))
+}
+
+// Logger logs for one component; the scoped logger of the context given to each call is used
+type Logger struct {
+	component string
+}
+
+// Named returns the logger of component (crawler, sync, admin...)
+func Named(component string) Logger {
+	return Logger{component: component}
+}
+
+func (l Logger) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	base := FromContext(ctx)
+	if !base.Enabled(ctx, level) {
+		return
+	}
+	base.With("component", l.component).Log(ctx, level, msg, args...)
+}
+
+func (l Logger) Debug(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelDebug, msg, args...)
+}
+
+func (l Logger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+func (l Logger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (l Logger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, msg, args...)
+}
+
+// Fatal logs at error level and exits
+func (l Logger) Fatal(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, msg, args...)
+	os.Exit(1)
+}
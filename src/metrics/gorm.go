@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin times every statement into DBQueryDuration and exports the connection
+// pool statistics of the database
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	steps := []struct {
+		operation string
+		before    func(string) error
+		after     func(string) error
+	}{
+		{"create",
+			func(n string) error { return cb.Create().Before("gorm:create").Register(n, startTimer) },
+			func(n string) error { return cb.Create().After("gorm:create").Register(n, observe("create")) }},
+		{"query",
+			func(n string) error { return cb.Query().Before("gorm:query").Register(n, startTimer) },
+			func(n string) error { return cb.Query().After("gorm:query").Register(n, observe("query")) }},
+		{"update",
+			func(n string) error { return cb.Update().Before("gorm:update").Register(n, startTimer) },
+			func(n string) error { return cb.Update().After("gorm:update").Register(n, observe("update")) }},
+		{"delete",
+			func(n string) error { return cb.Delete().Before("gorm:delete").Register(n, startTimer) },
+			func(n string) error { return cb.Delete().After("gorm:delete").Register(n, observe("delete")) }},
+		{"row",
+			func(n string) error { return cb.Row().Before("gorm:row").Register(n, startTimer) },
+			func(n string) error { return cb.Row().After("gorm:row").Register(n, observe("row")) }},
+		{"raw",
+			func(n string) error { return cb.Raw().Before("gorm:raw").Register(n, startTimer) },
+			func(n string) error { return cb.Raw().After("gorm:raw").Register(n, observe("raw")) }},
+	}
+	for _, s := range steps {
+		if err := s.before("metrics:before_" + s.operation); err != nil {
+			return err
+		}
+		if err := s.after("metrics:after_" + s.operation); err != nil {
+			return err
+		}
+	}
+
+	// Pool statistics (open, in use, idle, waits) of the underlying sql.DB
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	err = prometheus.Register(collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name()))
+	var already prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &already) {
+		return err
+	}
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+		DBQueryDuration.WithLabelValues(operation, result(err)).Observe(time.Since(start).Seconds())
+	}
+}
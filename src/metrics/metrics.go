@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Every collector lives in the default registry, next to the Go runtime and process
+// collectors. Label values are kept to bounded sets: gin route templates, cache key
+// prefixes, upstream endpoint templates and job names.
+const namespace = "ani4s"
+
+var (
+	// HTTPRequestDuration is the latency of every request by route template
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DependencyUp is the result of the last readiness check of each dependency
+	DependencyUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dependency_up",
+		Help:      "Whether the last health check of a dependency (postgres, redis, minio, upstream) succeeded.",
+	}, []string{"dependency"})
+
+	// CacheFallbackActive is 1 while Redis is unreachable and the in-process cache serves
+	CacheFallbackActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cache_fallback_active",
+		Help:      "1 while Redis is unreachable and payloads are cached in process instead.",
+	})
+
+	// CacheTierLookups counts the lookups of the two-tier cache by the tier that answered
+	CacheTierLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_tier_lookups_total",
+		Help:      "Cache lookups by tier that answered: l1 (in process), l2 (Redis) or miss.",
+	}, []string{"tier"})
+
+	// CacheRequests counts Redis cache lookups by key prefix
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Redis cache lookups by tag (key prefix) and result (hit or miss).",
+	}, []string{"tag", "result"})
+
+	// UpstreamDuration is the latency of phimapi requests by endpoint template
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of upstream (phimapi) requests by endpoint template.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"endpoint"})
+
+	// UpstreamErrors counts failed phimapi requests by endpoint template and reason
+	UpstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Failed upstream requests by endpoint template and reason (request, status or read).",
+	}, []string{"endpoint", "reason"})
+
+	// SingleflightCalls counts callers of a singleflight group; calls minus executions is
+	// the number of upstream fetches saved by deduplication
+	SingleflightCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "singleflight_calls_total",
+		Help:      "Callers of a singleflight group.",
+	}, []string{"group"})
+
+	// SingleflightExecutions counts the calls that actually ran the shared function
+	SingleflightExecutions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "singleflight_executions_total",
+		Help:      "Executions of the shared function of a singleflight group.",
+	}, []string{"group"})
+
+	// JobDuration is the duration of background job runs by outcome
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of background job runs by job and outcome (success, failure or panic).",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 60, 300, 900, 3600, 14400},
+	}, []string{"job", "outcome"})
+
+	// WebSocketClients is the number of open WebSocket connections
+	WebSocketClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Open WebSocket connections.",
+	})
+
+	// MinioOperations is the latency of MinIO calls by operation and result
+	MinioOperations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "minio_operation_duration_seconds",
+		Help:      "Latency of MinIO object operations by operation and result (ok or error).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "result"})
+
+	// DBQueryDuration is the latency of GORM statements by operation and result
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of database statements by operation and result (ok or error).",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"operation", "result"})
+)
+
+// Handler serves the default registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// CacheTag returns the label of a cache key: its prefix up to the first colon
+func CacheTag(cacheKey string) string {
+	if i := strings.IndexByte(cacheKey, ':'); i > 0 {
+		return cacheKey[:i]
+	}
+	return cacheKey
+}
+
+// ObserveCache counts a lookup of cacheKey
+func ObserveCache(cacheKey string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(CacheTag(cacheKey), result).Inc()
+}
+
+// ObserveMinio records a MinIO call that started at start
+func ObserveMinio(operation string, start time.Time, err error) {
+	MinioOperations.WithLabelValues(operation, result(err)).Observe(time.Since(start).Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
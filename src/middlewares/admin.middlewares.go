@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"ani4s/src/config"
+	"ani4s/src/utils"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth protects admin routes with the ADMIN_TOKEN shared secret, sent as
+// `Authorization: Bearer <token>` or `X-Admin-Token: <token>`.
+// Without ADMIN_TOKEN the admin API is disabled.
+func AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.App.Server.AdminToken
+		if expected == "" {
+			utils.RespondError(c, http.StatusServiceUnavailable, utils.ErrCodeDisabled, "admin API is disabled: ADMIN_TOKEN is not set")
+			c.Abort()
+			return
+		}
+
+		token := c.GetHeader("X-Admin-Token")
+		if auth := c.GetHeader("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			utils.RespondError(c, http.StatusUnauthorized, utils.ErrCodeUnauthorized, "invalid or missing admin token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"ani4s/src/logger"
+	"ani4s/src/tracing"
+	"ani4s/src/utils"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request id in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern accepts ids from proxies and clients that are safe to log and echo
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+var httpLog = logger.Named("http")
+
+// RequestID reuses a valid incoming X-Request-ID or assigns a new one, echoes it on the
+// response and scopes the request context logger with it and with the trace id, if traced
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = logger.NewID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Set(utils.RequestIDKey, id)
+
+		ctx := logger.With(c.Request.Context(), "request_id", id)
+		if traceID, _ := tracing.IDs(ctx); traceID != "" {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+			ctx = logger.With(ctx, "trace_id", traceID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// AccessLog logs one record per request once it has been served; 5xx are errors, 4xx warnings
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		args := []interface{}{
+			"method", c.Request.Method,
+			"route", path,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", strings.Join(c.Errors.Errors(), "; "))
+		}
+
+		ctx := c.Request.Context()
+		switch level {
+		case slog.LevelError:
+			httpLog.Error(ctx, "request", args...)
+		case slog.LevelWarn:
+			httpLog.Warn(ctx, "request", args...)
+		default:
+			httpLog.Info(ctx, "request", args...)
+		}
+	}
+}
+
+// Recovery turns a panic into a 500 error response and logs it with its stack, so it
+// carries the request id like every other record
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		httpLog.Error(c.Request.Context(), "panic recovered",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()),
+		)
+		utils.RespondError(c, http.StatusInternalServerError, utils.ErrCodeInternal, "internal server error")
+	})
+}
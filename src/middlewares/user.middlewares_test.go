@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"ani4s/src/config"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserIdentity(t *testing.T) {
+	const secret = "user-secret"
+	token := SignUserToken(secret, 42)
+	_, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name   string
+		secret string
+		auth   string
+		status int
+		userID uint
+	}{
+		{"anonymous", secret, "", http.StatusOK, 0},
+		{"valid token", secret, "Bearer " + token, http.StatusOK, 42},
+		{"signature of another user", secret, "Bearer 7." + signature, http.StatusUnauthorized, 0},
+		{"signed with another secret", secret, "Bearer " + SignUserToken("other", 42), http.StatusUnauthorized, 0},
+		{"bare user id", secret, "Bearer 42", http.StatusUnauthorized, 0},
+		{"user zero", secret, "Bearer " + SignUserToken(secret, 0), http.StatusUnauthorized, 0},
+		{"not a bearer token", secret, "Basic " + token, http.StatusUnauthorized, 0},
+		{"no secret: anonymous", "", "Bearer " + token, http.StatusOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.App
+			t.Cleanup(func() { config.App = previous })
+			config.App = config.Defaults()
+			config.App.Server.UserTokenSecret = tt.secret
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/", UserIdentity(), func(c *gin.Context) {
+				c.String(http.StatusOK, strconv.FormatUint(uint64(UserID(c)), 10))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if want := strconv.FormatUint(uint64(tt.userID), 10); tt.status == http.StatusOK && rec.Body.String() != want {
+				t.Errorf("user = %s, want %s", rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	const secret = "user-secret"
+
+	tests := []struct {
+		name   string
+		secret string
+		auth   string
+		status int
+	}{
+		{"valid token", secret, "Bearer " + SignUserToken(secret, 42), http.StatusOK},
+		{"anonymous", secret, "", http.StatusUnauthorized},
+		{"forged token", secret, "Bearer " + SignUserToken("guess", 42), http.StatusUnauthorized},
+		{"no secret", "", "Bearer " + SignUserToken("", 42), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.App
+			t.Cleanup(func() { config.App = previous })
+			config.App = config.Defaults()
+			config.App.Server.UserTokenSecret = tt.secret
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/", RequireUser(), func(c *gin.Context) {
+				if UserID(c) != 42 {
+					t.Errorf("user = %d, want 42", UserID(c))
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
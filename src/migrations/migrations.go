@@ -0,0 +1,332 @@
+package migrations
+
+import (
+	"ani4s/src/logger"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var migrateLog = logger.Named("migrate")
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey is the pg_advisory_lock key held while migrating, so only one replica runs them
+const lockKey int64 = 0x616e693473 // "ani4s"
+
+// noTransaction marks a file that must run outside a transaction (CREATE INDEX CONCURRENTLY...);
+// its statements run one by one
+const noTransaction = "-- migrate:no-transaction"
+
+var filePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered pair of up/down scripts
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with the time it was applied, if any
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Load reads the embedded migrations sorted by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		m := filePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(files, "sql/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up applies pending migrations; steps <= 0 applies all of them
+func Up(db *sql.DB, steps int) error {
+	return withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		all, err := Load()
+		if err != nil {
+			return err
+		}
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, mig := range all {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if steps > 0 && count >= steps {
+				break
+			}
+			migrateLog.Info(ctx, "applying migration", "version", mig.Version, "name", mig.Name)
+			err := run(ctx, conn, mig.Up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())",
+				mig.Version, mig.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			count++
+		}
+		if count == 0 {
+			migrateLog.Info(ctx, "schema is up to date")
+		}
+		return nil
+	})
+}
+
+// Down rolls back the latest applied migrations; steps <= 0 rolls back one
+func Down(db *sql.DB, steps int) error {
+	if steps <= 0 {
+		steps = 1
+	}
+	return withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		all, err := Load()
+		if err != nil {
+			return err
+		}
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for i := len(all) - 1; i >= 0 && count < steps; i-- {
+			mig := all[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+			}
+			migrateLog.Info(ctx, "rolling back migration", "version", mig.Version, "name", mig.Name)
+			err := run(ctx, conn, mig.Down, "DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+			if err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+}
+
+// List reports every embedded migration and when it was applied
+func List(db *sql.DB) ([]Status, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Status, 0, len(all))
+	for _, mig := range all {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func withLock(db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(ctx, conn)
+}
+
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// run executes a script and its bookkeeping statement, in one transaction unless the script
+// opts out. Postgres runs a multi-statement string as one implicit transaction, so a script
+// that opts out is sent one statement at a time.
+func run(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+	if strings.HasPrefix(strings.TrimSpace(script), noTransaction) {
+		for _, stmt := range splitStatements(script) {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		_, err := conn.ExecContext(ctx, bookkeeping, args...)
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements cuts a script at the semicolons that end its statements, skipping those
+// inside comments, quoted strings and identifiers, and dollar-quoted bodies. Statements
+// holding nothing but comments are dropped.
+func splitStatements(script string) []string {
+	var out []string
+	start, code := 0, false
+	flush := func(end int) {
+		if stmt := strings.TrimSpace(script[start:end]); stmt != "" && code {
+			out = append(out, stmt)
+		}
+		start, code = end+1, false
+	}
+
+	for i := 0; i < len(script); i++ {
+		rest := script[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case script[i] == '\'' || script[i] == '"':
+			code = true
+			for i++; i < len(script); i++ {
+				if script[i] == rest[0] {
+					// A doubled quote is an escaped one
+					if i+1 < len(script) && script[i+1] == rest[0] {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case script[i] == '$' && dollarTag.MatchString(rest):
+			code = true
+			tag := dollarTag.FindString(rest)
+			if end := strings.Index(rest[len(tag):], tag); end >= 0 {
+				i += len(tag) + end + len(tag) - 1
+			} else {
+				i = len(script)
+			}
+		case script[i] == ';':
+			flush(i)
+		case script[i] > ' ':
+			code = true
+		}
+	}
+	if start < len(script) {
+		flush(len(script))
+	}
+	return out
+}
+
+// dollarTag matches the opening of a dollar-quoted body: $$ or $tag$
+var dollarTag = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*\$|^\$\$`)
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	all, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, mig := range all {
+		if want := int64(i + 1); mig.Version != want {
+			t.Errorf("migration %d_%s, want version %d: versions must follow each other", mig.Version, mig.Name, want)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+	}
+}
+
+var uniqueIndex = regexp.MustCompile(`(?i)CREATE\s+UNIQUE\s+INDEX`)
+
+// A rollback must not add a constraint the rows written since the migration can break
+func TestDownScriptsAddNoUniqueIndex(t *testing.T) {
+	all, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mig := range all {
+		for _, line := range strings.Split(mig.Down, "\n") {
+			if uniqueIndex.MatchString(line) {
+				t.Errorf("%04d_%s.down.sql creates a unique index: %s", mig.Version, mig.Name, strings.TrimSpace(line))
+			}
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"one", "CREATE INDEX CONCURRENTLY a ON t (x)", []string{"CREATE INDEX CONCURRENTLY a ON t (x)"}},
+		{
+			"several with the marker",
+			"-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY a ON t (x);\nCREATE INDEX CONCURRENTLY b ON t (y);\n",
+			[]string{"-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY a ON t (x)", "CREATE INDEX CONCURRENTLY b ON t (y)"},
+		},
+		{"trailing comment", "SELECT 1;\n-- done; really\n", []string{"SELECT 1"}},
+		{"block comment", "SELECT /* a; b */ 1; SELECT 2", []string{"SELECT /* a; b */ 1", "SELECT 2"}},
+		{"string", "INSERT INTO t VALUES ('a;b', 'it''s;'); SELECT 2", []string{"INSERT INTO t VALUES ('a;b', 'it''s;')", "SELECT 2"}},
+		{"identifier", `ALTER TABLE "odd;name" ADD c int; SELECT 2`, []string{`ALTER TABLE "odd;name" ADD c int`, "SELECT 2"}},
+		{
+			"dollar quoted body",
+			"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; DO $$ BEGIN PERFORM 1; END $$;",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql", "DO $$ BEGIN PERFORM 1; END $$"},
+		},
+		{"empty statements", ";;\n;", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !slices.Equal(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
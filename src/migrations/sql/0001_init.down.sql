@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS episodes;
+DROP TABLE IF EXISTS movie_countries;
+DROP TABLE IF EXISTS movie_categories;
+DROP TABLE IF EXISTS countries;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS movies;
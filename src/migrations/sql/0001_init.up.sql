@@ -0,0 +1,73 @@
+-- Baseline: the schema previously produced by GORM AutoMigrate.
+-- IF NOT EXISTS keeps it safe on databases that were auto-migrated before.
+
+CREATE TABLE IF NOT EXISTS movies (
+    id              varchar(64) PRIMARY KEY,
+    name            text,
+    slug            varchar(255),
+    origin_name     text,
+    content         text,
+    type            text,
+    status          text,
+    poster_url      text,
+    thumb_url       text,
+    is_copyright    boolean,
+    sub_doc_quyen   boolean,
+    chieu_rap       boolean,
+    trailer_url     text,
+    time            text,
+    episode_current text,
+    episode_total   text,
+    quality         text,
+    lang            text,
+    notify          text,
+    showtimes       text,
+    year            bigint,
+    view            bigint,
+    actor           text[],
+    director        text[],
+    season          bigint,
+    vote_average    numeric,
+    vote_count      bigint
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_slug ON movies (slug);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id   text PRIMARY KEY,
+    name text,
+    slug text
+);
+CREATE INDEX IF NOT EXISTS idx_categories_name ON categories (name);
+CREATE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
+
+CREATE TABLE IF NOT EXISTS countries (
+    id   text PRIMARY KEY,
+    name text,
+    slug text
+);
+CREATE INDEX IF NOT EXISTS idx_countries_name ON countries (name);
+CREATE INDEX IF NOT EXISTS idx_countries_slug ON countries (slug);
+
+CREATE TABLE IF NOT EXISTS movie_categories (
+    movie_id    varchar(64) REFERENCES movies (id),
+    category_id text REFERENCES categories (id),
+    PRIMARY KEY (movie_id, category_id)
+);
+
+CREATE TABLE IF NOT EXISTS movie_countries (
+    movie_id   varchar(64) REFERENCES movies (id),
+    country_id text REFERENCES countries (id),
+    PRIMARY KEY (movie_id, country_id)
+);
+
+CREATE TABLE IF NOT EXISTS episodes (
+    id          bigserial PRIMARY KEY,
+    movie_id    varchar(64) NOT NULL REFERENCES movies (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    server_name text,
+    name        text,
+    slug        varchar(255),
+    filename    text,
+    link_embed  text,
+    link_m3_u8  text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_episodes_slug ON episodes (slug);
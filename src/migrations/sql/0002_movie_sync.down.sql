@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS movie_changes;
+
+DROP INDEX IF EXISTS idx_episodes_movie_server_slug;
+ALTER TABLE episodes ALTER COLUMN server_name TYPE text;
+-- Not unique again: synced episode slugs repeat across movies
+CREATE INDEX IF NOT EXISTS idx_episodes_slug ON episodes (slug);
+
+ALTER TABLE movies DROP COLUMN IF EXISTS modified_time;
+ALTER TABLE movies DROP COLUMN IF EXISTS created_time;
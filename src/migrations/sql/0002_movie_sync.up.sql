@@ -0,0 +1,21 @@
+-- Incremental sync: upstream timestamps, per-movie episode keys and the change log
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS created_time timestamptz;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS modified_time timestamptz;
+
+-- Episode slugs (tap-01, full...) repeat across movies
+DROP INDEX IF EXISTS idx_episodes_slug;
+ALTER TABLE episodes ALTER COLUMN server_name TYPE varchar(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_episodes_movie_server_slug ON episodes (movie_id, server_name, slug);
+
+CREATE TABLE IF NOT EXISTS movie_changes (
+    id         bigserial PRIMARY KEY,
+    movie_id   varchar(64) NOT NULL,
+    kind       varchar(32),
+    field      text,
+    old_value  text,
+    new_value  text,
+    changed_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_movie_changes_movie_id ON movie_changes (movie_id);
+CREATE INDEX IF NOT EXISTS idx_movie_changes_changed_at ON movie_changes (changed_at);
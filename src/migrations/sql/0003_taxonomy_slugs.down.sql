@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_countries_slug_unique;
+ALTER TABLE countries ALTER COLUMN slug TYPE text;
+CREATE INDEX IF NOT EXISTS idx_countries_slug ON countries (slug);
+
+DROP INDEX IF EXISTS idx_categories_slug_unique;
+ALTER TABLE categories ALTER COLUMN slug TYPE text;
+CREATE INDEX IF NOT EXISTS idx_categories_slug ON categories (slug);
@@ -0,0 +1,22 @@
+-- Categories and countries are keyed by slug.
+-- Rows synced from /the-loai and /quoc-gia before `_id` was read have an empty ID.
+
+DELETE FROM movie_categories WHERE category_id IN (SELECT id FROM categories WHERE id = '' OR slug IS NULL OR slug = '');
+DELETE FROM categories WHERE id = '' OR slug IS NULL OR slug = '';
+DELETE FROM movie_categories mc USING categories a, categories b
+    WHERE mc.category_id = a.id AND a.slug = b.slug AND a.id > b.id;
+DELETE FROM categories a USING categories b WHERE a.slug = b.slug AND a.id > b.id;
+
+DELETE FROM movie_countries WHERE country_id IN (SELECT id FROM countries WHERE id = '' OR slug IS NULL OR slug = '');
+DELETE FROM countries WHERE id = '' OR slug IS NULL OR slug = '';
+DELETE FROM movie_countries mc USING countries a, countries b
+    WHERE mc.country_id = a.id AND a.slug = b.slug AND a.id > b.id;
+DELETE FROM countries a USING countries b WHERE a.slug = b.slug AND a.id > b.id;
+
+DROP INDEX IF EXISTS idx_categories_slug;
+ALTER TABLE categories ALTER COLUMN slug TYPE varchar(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_slug_unique ON categories (slug);
+
+DROP INDEX IF EXISTS idx_countries_slug;
+ALTER TABLE countries ALTER COLUMN slug TYPE varchar(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_countries_slug_unique ON countries (slug);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS movie_overrides;
+DROP TABLE IF EXISTS category_overrides;
@@ -0,0 +1,25 @@
+-- Admin overrides layered on top of the upstream catalog
+
+CREATE TABLE IF NOT EXISTS category_overrides (
+    slug       varchar(255) PRIMARY KEY,
+    name       text NOT NULL DEFAULT '',
+    hidden     boolean NOT NULL DEFAULT false,
+    reason     text NOT NULL DEFAULT '',
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS movie_overrides (
+    slug       varchar(255) PRIMARY KEY,
+    name       text NOT NULL DEFAULT '',
+    poster_url text NOT NULL DEFAULT '',
+    thumb_url  text NOT NULL DEFAULT '',
+    content    text NOT NULL DEFAULT '',
+    hidden     boolean NOT NULL DEFAULT false,
+    reason     text NOT NULL DEFAULT '',
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- Replaces the rename that used to be hardcoded in ListAllCategories
+INSERT INTO category_overrides (slug, name, reason)
+VALUES ('mien-tay', 'Cao Bồi', 'Miền Tây is shown as Cao Bồi')
+ON CONFLICT (slug) DO NOTHING;
@@ -0,0 +1,18 @@
+-- Admin-curated collections rendered as homepage sections
+
+CREATE TABLE IF NOT EXISTS collections (
+    slug       varchar(255) PRIMARY KEY,
+    title      text NOT NULL,
+    kind       varchar(16) NOT NULL CHECK (kind IN ('manual', 'query')),
+    slugs      text[] NOT NULL DEFAULT '{}',
+    filter     jsonb,
+    "limit"    integer NOT NULL DEFAULT 20,
+    position   integer NOT NULL DEFAULT 0,
+    enabled    boolean NOT NULL DEFAULT true,
+    starts_at  timestamptz,
+    ends_at    timestamptz,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collections_position ON collections (position, slug);
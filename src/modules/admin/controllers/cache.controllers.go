@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"ani4s/src/logger"
+	lib "ani4s/src/modules/admin/lib"
+	movieslib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/services"
+	"ani4s/src/utils"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var adminLog = logger.Named("admin")
+
+// ListCacheTags returns every tag with its key count and memory usage
+func ListCacheTags(c *gin.Context) {
+	stats, err := movies.CacheTagStats()
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, stats, nil, nil)
+}
+
+// GetCacheStats returns the L1 (in-process) and L2 (Redis) hit rates of this replica
+func GetCacheStats(c *gin.Context) {
+	utils.RespondSuccess(c, movies.CacheTierStats(), nil, nil)
+}
+
+// ListCacheTagKeys returns the keys indexed by one tag
+func ListCacheTagKeys(c *gin.Context) {
+	tag := c.Param("tag")
+	keys, err := movies.TagMembers(tag)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, lib.CacheTagKeysResponse{Tag: tag, Keys: keys}, nil, nil)
+}
+
+// InspectCacheKey returns one cached payload with its TTL and size
+func InspectCacheKey(c *gin.Context) {
+	var req lib.CacheKeyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	entry, err := movies.InspectKey(req.Key)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, entry, nil, nil)
+}
+
+// PurgeCacheTag deletes every payload of a tag
+func PurgeCacheTag(c *gin.Context) {
+	tag := c.Param("tag")
+	if !movies.IsCacheTag(tag) {
+		utils.RespondError(c, http.StatusNotFound, utils.ErrCodeNotFound, "unknown cache tag: "+tag)
+		return
+	}
+	removed, err := movies.FlushTag(tag)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "purged cache tag", "tag", tag, "removed", removed)
+	utils.RespondSuccess(c, lib.CachePurgeResponse{Removed: removed}, nil, nil)
+}
+
+// PurgeCacheMovie deletes the details of a movie and every cached list containing it
+func PurgeCacheMovie(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := movieslib.ValidateSlug(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	removed, err := movies.PurgeMovie(slug)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "purged cached movie", "slug", slug, "removed", removed)
+	utils.RespondSuccess(c, lib.CachePurgeResponse{Removed: removed}, nil, nil)
+}
+
+// PurgeCachePrefix deletes every key starting with a prefix
+func PurgeCachePrefix(c *gin.Context) {
+	var req lib.CachePrefixRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	removed, err := movies.PurgePrefix(req.Prefix)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "purged cache prefix", "prefix", req.Prefix, "removed", removed)
+	utils.RespondSuccess(c, lib.CachePurgeResponse{Removed: removed}, nil, nil)
+}
+
+// RefreshCache re-fetches a movie synchronously, or a whole tag in the background
+func RefreshCache(c *gin.Context) {
+	var req lib.CacheRefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+
+	v := &utils.ValidationError{}
+	switch {
+	case req.Tag == "" && req.Slug == "":
+		v.Add("tag", "tag or slug is required")
+	case req.Tag != "" && req.Slug != "":
+		v.Add("slug", "set either tag or slug, not both")
+	case req.Tag != "" && !movies.IsCacheTag(req.Tag):
+		v.Add("tag", "unknown cache tag")
+	case req.Slug != "":
+		if err := movieslib.ValidateSlug(req.Slug); err != nil {
+			v.Add("slug", "must be a lowercase slug")
+		}
+	}
+	if err := v.OrNil(); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	if req.Slug != "" {
+		if err := movies.RefreshMovie(c.Request.Context(), req.Slug); err != nil {
+			utils.RespondServiceError(c, err)
+			return
+		}
+		utils.RespondSuccess(c, lib.CacheRefreshResponse{Slug: req.Slug, Status: "refreshed"}, nil, nil)
+		return
+	}
+
+	// The refresh outlives the request but keeps its request id in the job logs
+	go services.RunJob(context.WithoutCancel(c.Request.Context()), "refresh:"+req.Tag, func(ctx context.Context) error {
+		res, err := movies.RefreshTag(ctx, req.Tag)
+		if err != nil {
+			return err
+		}
+		adminLog.Info(ctx, "refreshed cache tag", "tag", req.Tag, "refreshed", res.Refreshed, "dropped", res.Dropped)
+		return nil
+	})
+
+	c.JSON(http.StatusAccepted, utils.Response{
+		Success: true,
+		Data:    lib.CacheRefreshResponse{Tag: req.Tag, Status: "accepted"},
+	})
+}
@@ -0,0 +1,109 @@
+package admin
+
+import (
+	lib "ani4s/src/modules/admin/lib"
+	movieslib "ani4s/src/modules/movies/lib"
+	models "ani4s/src/modules/movies/models"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListCollections returns every collection in homepage order
+func ListCollections(c *gin.Context) {
+	all, err := movies.ListCollections()
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, all, nil, nil)
+}
+
+// PreviewCollection renders a collection now, ignoring its schedule
+func PreviewCollection(c *gin.Context) {
+	section, err := movies.PreviewCollection(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, section, nil, nil)
+}
+
+// SaveCollection creates or replaces a collection
+func SaveCollection(c *gin.Context) {
+	var req lib.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+
+	col := &models.Collection{
+		Slug:     c.Param("slug"),
+		Title:    req.Title,
+		Kind:     req.Kind,
+		Slugs:    req.Slugs,
+		Filter:   req.Filter,
+		Limit:    req.Limit,
+		Position: req.Position,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	}
+	if err := movieslib.ValidateCollection(col); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	if err := movies.SaveCollection(col); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "collection saved", "collection", col.Slug, "kind", col.Kind, "enabled", col.Enabled)
+	utils.RespondSuccess(c, col, nil, nil)
+}
+
+// DeleteCollection removes a collection
+func DeleteCollection(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := movies.DeleteCollection(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "collection removed", "collection", slug)
+	utils.RespondSuccess(c, gin.H{"slug": slug}, nil, nil)
+}
+
+// ReorderCollections sets the homepage order of the listed collections
+func ReorderCollections(c *gin.Context) {
+	var req lib.CollectionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+
+	v := &utils.ValidationError{}
+	seen := make(map[string]bool, len(req.Slugs))
+	for _, slug := range req.Slugs {
+		if seen[slug] {
+			v.Add("slugs", "lists "+slug+" twice")
+		}
+		seen[slug] = true
+	}
+	if err := v.OrNil(); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	if err := movies.ReorderCollections(req.Slugs); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	all, err := movies.ListCollections()
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "collections reordered", "slugs", req.Slugs)
+	utils.RespondSuccess(c, all, nil, nil)
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	lib "ani4s/src/modules/admin/lib"
+	movieslib "ani4s/src/modules/movies/lib"
+	models "ani4s/src/modules/movies/models"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListOverrides returns every category and movie override
+func ListOverrides(c *gin.Context) {
+	cats, movs, err := movies.ListOverrides()
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, lib.OverridesResponse{Categories: cats, Movies: movs}, nil, nil)
+}
+
+// SaveCategoryOverride creates or replaces the override of a category
+func SaveCategoryOverride(c *gin.Context) {
+	slug := c.Param("slug")
+	var req lib.CategoryOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+
+	v := &utils.ValidationError{}
+	if err := movieslib.ValidateSlug(slug); err != nil {
+		v.Add("slug", "must be a lowercase slug")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" && !req.Hidden {
+		v.Add("name", "set a name or hidden")
+	}
+	if err := v.OrNil(); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	o := &models.CategoryOverride{Slug: slug, Name: req.Name, Hidden: req.Hidden, Reason: req.Reason}
+	if err := movies.SaveCategoryOverride(o); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "category override saved", "slug", slug, "name", o.Name, "hidden", o.Hidden)
+	utils.RespondSuccess(c, o, nil, nil)
+}
+
+// DeleteCategoryOverride removes the override of a category
+func DeleteCategoryOverride(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := movies.DeleteCategoryOverride(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "category override removed", "slug", slug)
+	utils.RespondSuccess(c, gin.H{"slug": slug}, nil, nil)
+}
+
+// SaveMovieOverride creates or replaces the override of a movie
+func SaveMovieOverride(c *gin.Context) {
+	slug := c.Param("slug")
+	var req lib.MovieOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+
+	v := &utils.ValidationError{}
+	if err := movieslib.ValidateSlug(slug); err != nil {
+		v.Add("slug", "must be a lowercase slug")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" && req.PosterURL == "" && req.ThumbURL == "" && req.Content == "" && !req.Hidden {
+		v.Add("name", "set at least one of name, poster_url, thumb_url, content or hidden")
+	}
+	if err := v.OrNil(); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	o := &models.MovieOverride{
+		Slug:      slug,
+		Name:      req.Name,
+		PosterURL: req.PosterURL,
+		ThumbURL:  req.ThumbURL,
+		Content:   req.Content,
+		Hidden:    req.Hidden,
+		Reason:    req.Reason,
+	}
+	if err := movies.SaveMovieOverride(o); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "movie override saved", "slug", slug, "hidden", o.Hidden)
+	utils.RespondSuccess(c, o, nil, nil)
+}
+
+// DeleteMovieOverride removes the override of a movie
+func DeleteMovieOverride(c *gin.Context) {
+	slug := c.Param("slug")
+	if err := movies.DeleteMovieOverride(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	adminLog.Info(c.Request.Context(), "movie override removed", "slug", slug)
+	utils.RespondSuccess(c, gin.H{"slug": slug}, nil, nil)
+}
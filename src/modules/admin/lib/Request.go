@@ -0,0 +1,82 @@
+package admin
+
+import (
+	models "ani4s/src/modules/movies/models"
+	"time"
+)
+
+// CacheKeyRequest selects one cached payload
+type CacheKeyRequest struct {
+	Key string `json:"key" form:"key" binding:"required"`
+}
+
+// CachePrefixRequest selects every key starting with Prefix
+type CachePrefixRequest struct {
+	Prefix string `json:"prefix" form:"prefix" binding:"required"`
+}
+
+// CacheRefreshRequest refreshes a whole tag or a single movie; exactly one must be set
+type CacheRefreshRequest struct {
+	Tag  string `json:"tag"`
+	Slug string `json:"slug"`
+}
+
+// CachePurgeResponse reports how many keys a purge removed
+type CachePurgeResponse struct {
+	Removed int64 `json:"removed"`
+}
+
+// CacheTagKeysResponse lists the keys indexed by a tag
+type CacheTagKeysResponse struct {
+	Tag  string   `json:"tag"`
+	Keys []string `json:"keys"`
+}
+
+// CacheRefreshResponse acknowledges a refresh; tag refreshes run in the background
+type CacheRefreshResponse struct {
+	Tag    string `json:"tag,omitempty"`
+	Slug   string `json:"slug,omitempty"`
+	Status string `json:"status"` // "accepted" or "refreshed"
+}
+
+// CategoryOverrideRequest renames and/or hides a category
+type CategoryOverrideRequest struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden"`
+	Reason string `json:"reason"`
+}
+
+// MovieOverrideRequest replaces fields of a movie and/or hides it; empty fields keep upstream values
+type MovieOverrideRequest struct {
+	Name      string `json:"name"`
+	PosterURL string `json:"poster_url"`
+	ThumbURL  string `json:"thumb_url"`
+	Content   string `json:"content"`
+	Hidden    bool   `json:"hidden"`
+	Reason    string `json:"reason"`
+}
+
+// OverridesResponse lists every override
+type OverridesResponse struct {
+	Categories []models.CategoryOverride `json:"categories"`
+	Movies     []models.MovieOverride    `json:"movies"`
+}
+
+// CollectionRequest creates or replaces a collection. Manual collections list Slugs,
+// query collections set Filter; Enabled defaults to true.
+type CollectionRequest struct {
+	Title    string                   `json:"title"`
+	Kind     string                   `json:"kind" binding:"required"`
+	Slugs    []string                 `json:"slugs"`
+	Filter   *models.CollectionFilter `json:"filter"`
+	Limit    int                      `json:"limit"`
+	Position int                      `json:"position"`
+	Enabled  *bool                    `json:"enabled"`
+	StartsAt *time.Time               `json:"starts_at"`
+	EndsAt   *time.Time               `json:"ends_at"`
+}
+
+// CollectionOrderRequest lists collection slugs in their new homepage order
+type CollectionOrderRequest struct {
+	Slugs []string `json:"slugs" binding:"required"`
+}
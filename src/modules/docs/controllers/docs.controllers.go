@@ -0,0 +1,59 @@
+package docs
+
+import (
+	service "ani4s/src/modules/docs/services"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// swaggerIndex loads the embedded Swagger UI assets and points it at /openapi.json
+const swaggerIndex = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>ani4s API</title>
+  <link rel="stylesheet" type="text/css" href="./swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="./swagger-ui-bundle.js"></script>
+  <script src="./swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({
+        url: "/openapi.json",
+        dom_id: "#swagger-ui",
+        deepLinking: true,
+        presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>`
+
+// OpenAPISpec serves the OpenAPI document generated from the routes registered on router
+func OpenAPISpec(router *gin.Engine, ops map[string]service.Operation) gin.HandlerFunc {
+	var once sync.Once
+	var doc *service.Document
+	return func(c *gin.Context) {
+		// Routes are complete once the server handles requests, build lazily on first hit
+		once.Do(func() {
+			doc = service.Build(router.Routes(), ops)
+		})
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// SwaggerUI serves the Swagger UI bundled in the binary
+func SwaggerUI(c *gin.Context) {
+	asset := c.Param("any")
+	if asset == "" || asset == "/" || asset == "/index.html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerIndex))
+		return
+	}
+	c.FileFromFS(asset, swaggerFiles.HTTP)
+}
@@ -0,0 +1,393 @@
+package docs
+
+import (
+	"ani4s/src/utils"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route registered on the router
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Body        interface{} // JSON request body
+	Query       interface{} // struct bound from the query string (form tags)
+	Data        interface{} // `data` of the success envelope
+	Paginated   bool
+	Raw         interface{} // response body for routes that do not use the envelope
+	ContentType string      // response content type, defaults to application/json
+	Admin       bool        // requires the admin token
+	User        UserToken   // reads the user token
+}
+
+// UserToken tells whether an operation reads the user token
+type UserToken int
+
+const (
+	NoUserToken       UserToken = iota
+	OptionalUserToken           // anonymous requests are served too
+	RequiredUserToken
+)
+
+// Document is the root of an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                        `json:"openapi"`
+	Info       Info                          `json:"info"`
+	Paths      map[string]map[string]*PathOp `json:"paths"`
+	Components Components                    `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type PathOp struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Build generates the OpenAPI document for every route of the router, using ops for the details
+func Build(routes gin.RoutesInfo, ops map[string]Operation) *Document {
+	b := &builder{schemas: map[string]*Schema{}}
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "ani4s API", Version: "v1"},
+		Paths:   map[string]map[string]*PathOp{},
+	}
+
+	for _, route := range routes {
+		op, ok := ops[route.Method+" "+route.Path]
+		if !ok {
+			op = Operation{Summary: route.Handler}
+		}
+		path, params := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*PathOp{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = b.operation(route, op, params)
+	}
+
+	b.schemaFor(reflect.TypeOf(utils.Pagination{}))
+	b.schemaFor(reflect.TypeOf(utils.Meta{}))
+	b.schemas["ErrorResponse"] = b.errorEnvelope()
+	doc.Components.Schemas = b.schemas
+	doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+		adminScheme: {Type: "http", Scheme: "bearer", Description: "ADMIN_TOKEN (also accepted as X-Admin-Token)"},
+		userScheme:  {Type: "http", Scheme: "bearer", Description: "user token `<userId>.<signature>` signed with USER_TOKEN_SECRET"},
+	}
+	return doc
+}
+
+// Validate reports routes without documentation and documented routes that are not registered
+func Validate(routes gin.RoutesInfo, ops map[string]Operation) []string {
+	var problems []string
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := ops[key]; !ok {
+			problems = append(problems, fmt.Sprintf("route %s is not documented", key))
+		}
+	}
+	for key := range ops {
+		if !registered[key] {
+			problems = append(problems, fmt.Sprintf("documented route %s is not registered", key))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// openAPIPath converts gin path params (:slug, *filepath) to OpenAPI templates
+func openAPIPath(ginPath string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(ginPath, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+const (
+	adminScheme = "adminToken"
+	userScheme  = "userToken"
+)
+
+type builder struct {
+	schemas map[string]*Schema
+}
+
+func (b *builder) operation(route gin.RouteInfo, op Operation, params []Parameter) *PathOp {
+	out := &PathOp{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		OperationID: operationID(route.Method, route.Path),
+		Parameters:  params,
+		Responses:   map[string]*Response{},
+	}
+
+	switch {
+	case op.Admin:
+		out.Security = []map[string][]string{{adminScheme: {}}}
+	case op.User == RequiredUserToken:
+		out.Security = []map[string][]string{{userScheme: {}}}
+	case op.User == OptionalUserToken:
+		out.Security = []map[string][]string{{userScheme: {}}, {}}
+	}
+	if op.Query != nil {
+		out.Parameters = append(out.Parameters, b.queryParameters(reflect.TypeOf(op.Query))...)
+	}
+	if op.Body != nil {
+		out.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(op.Body))}},
+		}
+	}
+
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	switch {
+	case op.Raw != nil:
+		out.Responses["200"] = &Response{
+			Description: "OK",
+			Content:     map[string]*MediaType{contentType: {Schema: b.schemaFor(reflect.TypeOf(op.Raw))}},
+		}
+	case op.Data != nil:
+		out.Responses["200"] = &Response{
+			Description: "OK",
+			Content:     map[string]*MediaType{contentType: {Schema: b.successEnvelope(op)}},
+		}
+	default:
+		out.Responses["200"] = &Response{Description: "OK"}
+	}
+
+	if op.Raw == nil {
+		errContent := map[string]*MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/ErrorResponse"}}}
+		out.Responses["4XX"] = &Response{Description: "Client error", Content: errContent}
+		out.Responses["5XX"] = &Response{Description: "Server or upstream error", Content: errContent}
+	}
+	return out
+}
+
+func (b *builder) successEnvelope(op Operation) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"data":    b.schemaFor(reflect.TypeOf(op.Data)),
+			"meta":    {Ref: "#/components/schemas/Meta"},
+		},
+		Required: []string{"success", "data"},
+	}
+	if op.Paginated {
+		s.Properties["pagination"] = &Schema{Ref: "#/components/schemas/Pagination"}
+	}
+	return s
+}
+
+func (b *builder) errorEnvelope() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"error": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"code":    {Type: "string"},
+					"message": {Type: "string"},
+					"details": {},
+				},
+				Required: []string{"code", "message"},
+			},
+		},
+		Required: []string{"success", "error"},
+	}
+}
+
+func (b *builder) queryParameters(t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: strings.Contains(f.Tag.Get("binding"), "required"),
+			Schema:   b.schemaFor(f.Type),
+		})
+	}
+	return params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor reflects a Go type into a schema, registering named structs as components
+func (b *builder) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schemaFor(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.schemas[name]; !ok {
+			b.schemas[name] = &Schema{} // placeholder for recursive types
+			b.schemas[name] = b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (b *builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened, like encoding/json does
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := b.structSchema(ft)
+				for k, v := range embedded.Properties {
+					s.Properties[k] = v
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.schemaFor(f.Type)
+		if strings.Contains(f.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func operationID(method, path string) string {
+	var parts []string
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.TrimLeft(seg, ":*")
+		seg = strings.NewReplacer("-", "_", ".", "_").Replace(seg)
+		if seg != "" {
+			parts = append(parts, seg)
+		}
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
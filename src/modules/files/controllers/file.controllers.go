@@ -2,6 +2,7 @@ package files
 
 import (
 	file "ani4s/src/modules/files/services"
+	"ani4s/src/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,13 +11,13 @@ import (
 func FileController(c *gin.Context) {
 	filepath := c.Param("filepath")
 	if filepath == "" || filepath == "/" {
-		c.JSON(http.StatusBadRequest, gin.H{"e": "invalid filepath"})
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "invalid filepath")
 		return
 	}
 
-	reader, size, contentType, e := file.FileService(filepath)
+	reader, size, contentType, e := file.FileService(c.Request.Context(), filepath)
 	if e != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"err": e.Error()})
+		utils.RespondServiceError(c, e)
 		return
 	}
 
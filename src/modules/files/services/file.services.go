@@ -1,52 +1,63 @@
 package files
 
 import (
+	"ani4s/src/cache"
 	"ani4s/src/config"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
 	"ani4s/src/utils"
 	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/minio/minio-go/v7"
 )
 
-func FileService(filePath string) (io.Reader, int64, string, *utils.ServiceError) {
+var filesLog = logger.Named("files")
+
+func FileService(ctx context.Context, filePath string) (io.Reader, int64, string, *utils.ServiceError) {
 	objectKey := strings.TrimPrefix(filePath, "/")
 	minioClient := config.MinioClient
 	bucketName := config.BucketName
 	cacheKey := "image_cache:" + objectKey
 
 	// 1. Try to get from Redis cache
-	cached, err := config.RDB.Get(config.Ctx, cacheKey).Bytes()
+	cached, err := config.Cache.Get(ctx, cacheKey)
+	if err == nil {
+		cached, err = cache.UnmarshalRaw(cached)
+	}
+	metrics.ObserveCache(cacheKey, err == nil && len(cached) > 0)
 	if err == nil && len(cached) > 0 {
-		log.Printf("[CACHE HIT] %s", cacheKey)
+		filesLog.Debug(ctx, "image cache hit", "key", cacheKey)
 		contentType := http.DetectContentType(cached)
 		return bytes.NewReader(cached), int64(len(cached)), contentType, nil
 	}
-	log.Printf("[CACHE MISS] %s", cacheKey)
+	filesLog.Debug(ctx, "image cache miss", "key", cacheKey)
 
 	// 2. Try to get from MinIO
-	obj, err := minioClient.GetObject(context.Background(), bucketName, objectKey, minio.GetObjectOptions{})
-	if err == nil {
+	opCtx, done := utils.TrackMinio(ctx, "get", objectKey)
+	obj, err := minioClient.GetObject(opCtx, bucketName, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		done(err)
+	} else {
 		stat, err := obj.Stat()
+		done(err)
 		if err == nil {
 			// Read all content to cache it
 			data, err := io.ReadAll(obj)
 			if err == nil {
 				// Set to Redis (with TTL if desired)
-				_ = config.RDB.Set(config.Ctx, cacheKey, data, 6*time.Hour).Err()
+				_ = config.Cache.Set(ctx, cacheKey, config.CacheCodec.MarshalRaw(data), config.App.Cache.TTL.ImageStored)
 				return bytes.NewReader(data), stat.Size, stat.ContentType, nil
 			}
 		}
 	}
 
 	// 3. Fallback: Try to download
-	newPath, err := utils.DownloadImageIfNotExist("https://phimimg.com/" + objectKey)
+	newPath, err := utils.DownloadImageIfNotExist(ctx, "https://phimimg.com/"+objectKey)
 	if err != nil {
 		return nil, 0, "", &utils.ServiceError{
 			StatusCode: http.StatusNotFound,
@@ -55,14 +66,17 @@ func FileService(filePath string) (io.Reader, int64, string, *utils.ServiceError
 	}
 
 	// Retry fetch
-	obj, err = minioClient.GetObject(context.Background(), bucketName, newPath, minio.GetObjectOptions{})
+	opCtx, done = utils.TrackMinio(ctx, "get", newPath)
+	obj, err = minioClient.GetObject(opCtx, bucketName, newPath, minio.GetObjectOptions{})
 	if err != nil {
+		done(err)
 		return nil, 0, "", &utils.ServiceError{
 			StatusCode: http.StatusNotFound,
 			Message:    fmt.Sprintf("downloaded but failed to retrieve object: %s", newPath),
 		}
 	}
 	stat, err := obj.Stat()
+	done(err)
 	if err != nil {
 		return nil, 0, "", &utils.ServiceError{
 			StatusCode: http.StatusNotFound,
@@ -72,7 +86,7 @@ func FileService(filePath string) (io.Reader, int64, string, *utils.ServiceError
 	// Cache after download
 	data, err := io.ReadAll(obj)
 	if err == nil {
-		_ = config.RDB.Set(config.Ctx, cacheKey, data, 24*time.Hour).Err()
+		_ = config.Cache.Set(ctx, cacheKey, config.CacheCodec.MarshalRaw(data), config.App.Cache.TTL.Image)
 	}
 	return bytes.NewReader(data), int64(len(data)), stat.ContentType, nil
 }
@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	service "ani4s/src/modules/graphql/services"
+	"ani4s/src/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLRequest is the standard GraphQL-over-HTTP request body
+type GraphQLRequest struct {
+	Query         string                 `json:"query" form:"query" binding:"required"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables" form:"-"`
+}
+
+// GraphQLHandler executes a query from a JSON body (POST) or the query string (GET)
+func GraphQLHandler(c *gin.Context) {
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		if err := c.ShouldBindQuery(&req); err != nil {
+			utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, err.Error())
+			return
+		}
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondError(c, http.StatusBadRequest, utils.ErrCodeBadRequest, err.Error())
+		return
+	}
+
+	// GraphQL responses keep the spec shape ({data, errors}) instead of the REST envelope
+	result := service.Execute(c.Request.Context(), req.Query, req.OperationName, req.Variables)
+	c.JSON(http.StatusOK, result)
+}
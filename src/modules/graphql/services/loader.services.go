@@ -0,0 +1,166 @@
+package graphql
+
+import (
+	"ani4s/src/config"
+	movies "ani4s/src/modules/movies/models"
+	service "ani4s/src/modules/movies/services"
+	"context"
+	"sync"
+)
+
+// batchLoader collects keys requested by sibling resolvers and fetches them in one query.
+// graphql-go resolves thunks breadth-first, so every Load of one level is queued before the
+// first thunk runs and triggers the batch.
+type batchLoader struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	fetch   func(ctx context.Context, ids []string) (map[string]interface{}, error)
+	pending map[string]bool
+	results map[string]interface{}
+	errs    map[string]error // ids whose batch failed
+}
+
+func newBatchLoader(ctx context.Context, fetch func(ctx context.Context, ids []string) (map[string]interface{}, error)) *batchLoader {
+	return &batchLoader{
+		ctx:     ctx,
+		fetch:   fetch,
+		pending: map[string]bool{},
+		results: map[string]interface{}{},
+		errs:    map[string]error{},
+	}
+}
+
+// Load queues id and returns a thunk resolving to its value
+func (l *batchLoader) Load(id string) func() (interface{}, error) {
+	l.mu.Lock()
+	_, done := l.results[id]
+	if _, failed := l.errs[id]; !done && !failed {
+		l.pending[id] = true
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			ids := make([]string, 0, len(l.pending))
+			for k := range l.pending {
+				ids = append(ids, k)
+			}
+			l.pending = map[string]bool{}
+
+			res, err := l.fetch(l.ctx, ids)
+			for _, k := range ids {
+				if err != nil {
+					// Every thunk of the batch fails, not only the one that ran it
+					l.errs[k] = err
+				} else {
+					l.results[k] = res[k]
+				}
+			}
+		}
+		if err := l.errs[id]; err != nil {
+			return nil, err
+		}
+		return l.results[id], nil
+	}
+}
+
+// Loaders holds the per-request batch loaders
+type Loaders struct {
+	Categories *batchLoader
+	Countries  *batchLoader
+	Episodes   *batchLoader
+}
+
+type loadersKey struct{}
+
+// WithLoaders attaches fresh loaders to ctx; call once per GraphQL request
+func WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &Loaders{
+		Categories: newBatchLoader(ctx, fetchCategories),
+		Countries:  newBatchLoader(ctx, fetchCountries),
+		Episodes:   newBatchLoader(ctx, fetchEpisodes),
+	})
+}
+
+func loadersFrom(ctx context.Context) *Loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return l
+	}
+	return WithLoaders(ctx).Value(loadersKey{}).(*Loaders)
+}
+
+// fetchCategories loads the categories of every movie in ids through movie_categories
+func fetchCategories(ctx context.Context, ids []string) (map[string]interface{}, error) {
+	type row struct {
+		MovieID string
+		movies.Category
+	}
+	var rows []row
+	if err := config.DB.WithContext(ctx).
+		Table("categories").
+		Select("movie_categories.movie_id, categories.id, categories.name, categories.slug").
+		Joins("JOIN movie_categories ON movie_categories.category_id = categories.id").
+		Where("movie_categories.movie_id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		out[id] = []movies.Category{}
+	}
+	for _, r := range rows {
+		out[r.MovieID] = append(out[r.MovieID].([]movies.Category), r.Category)
+	}
+	// Renamed / hidden categories, like every REST payload
+	for id, cats := range out {
+		out[id] = service.ApplyCategoryOverrides(ctx, cats.([]movies.Category))
+	}
+	return out, nil
+}
+
+// fetchCountries loads the countries of every movie in ids through movie_countries
+func fetchCountries(ctx context.Context, ids []string) (map[string]interface{}, error) {
+	type row struct {
+		MovieID string
+		movies.Country
+	}
+	var rows []row
+	if err := config.DB.WithContext(ctx).
+		Table("countries").
+		Select("movie_countries.movie_id, countries.id, countries.name, countries.slug").
+		Joins("JOIN movie_countries ON movie_countries.country_id = countries.id").
+		Where("movie_countries.movie_id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		out[id] = []movies.Country{}
+	}
+	for _, r := range rows {
+		out[r.MovieID] = append(out[r.MovieID].([]movies.Country), r.Country)
+	}
+	return out, nil
+}
+
+// fetchEpisodes loads the stored episodes of every movie in ids
+func fetchEpisodes(ctx context.Context, ids []string) (map[string]interface{}, error) {
+	var eps []movies.Episode
+	if err := config.DB.WithContext(ctx).Where("movie_id IN ?", ids).Order("id").Find(&eps).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(ids))
+	for _, id := range ids {
+		out[id] = []movies.Episode{}
+	}
+	for _, ep := range eps {
+		out[ep.MovieID] = append(out[ep.MovieID].([]movies.Episode), ep)
+	}
+	return out, nil
+}
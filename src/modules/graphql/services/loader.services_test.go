@@ -0,0 +1,56 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatchLoader(t *testing.T) {
+	var calls [][]string
+	l := newBatchLoader(context.Background(), func(_ context.Context, ids []string) (map[string]interface{}, error) {
+		calls = append(calls, ids)
+		out := map[string]interface{}{}
+		for _, id := range ids {
+			out[id] = "value " + id
+		}
+		return out, nil
+	})
+
+	a, b := l.Load("a"), l.Load("b")
+	if v, err := b(); err != nil || v != "value b" {
+		t.Errorf("b() = %v, %v", v, err)
+	}
+	if v, err := a(); err != nil || v != "value a" {
+		t.Errorf("a() = %v, %v", v, err)
+	}
+	if v, err := l.Load("a")(); err != nil || v != "value a" {
+		t.Errorf("a() again = %v, %v", v, err)
+	}
+	if len(calls) != 1 || len(calls[0]) != 2 {
+		t.Errorf("fetch calls = %q, want one batch of both ids", calls)
+	}
+}
+
+func TestBatchLoaderError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	l := newBatchLoader(context.Background(), func(context.Context, []string) (map[string]interface{}, error) {
+		calls++
+		return nil, boom
+	})
+
+	a, b := l.Load("a"), l.Load("b")
+	if v, err := a(); !errors.Is(err, boom) || v != nil {
+		t.Errorf("a() = %v, %v, want %v", v, err, boom)
+	}
+	if v, err := b(); !errors.Is(err, boom) || v != nil {
+		t.Errorf("b() = %v, %v, want the error of its batch %v", v, err, boom)
+	}
+	if _, err := l.Load("a")(); !errors.Is(err, boom) {
+		t.Errorf("a() again error = %v, want %v", err, boom)
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,421 @@
+package graphql
+
+import (
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	service "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+	"context"
+	"time"
+
+	gql "github.com/graphql-go/graphql"
+)
+
+// movieNode is the source value of the Movie type. Nil relations are loaded in batch.
+type movieNode struct {
+	movies.Movie
+	Episodes []movies.Episode
+}
+
+// movieListNode is the source value of the MovieList type
+type movieListNode struct {
+	Items      []*movieNode
+	Pagination utils.Pagination
+}
+
+// Schema is the GraphQL schema served at /graphql
+var Schema gql.Schema
+
+func init() {
+	schema, err := gql.NewSchema(gql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(err)
+	}
+	Schema = schema
+}
+
+// Execute runs a GraphQL request with fresh per-request loaders
+func Execute(ctx context.Context, query, operationName string, variables map[string]interface{}) *gql.Result {
+	return gql.Do(gql.Params{
+		Schema:         Schema,
+		RequestString:  query,
+		OperationName:  operationName,
+		VariableValues: variables,
+		Context:        WithLoaders(ctx),
+	})
+}
+
+// movieField builds a Movie field reading from the embedded model
+func movieField(t gql.Output, get func(m *movies.Movie) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: t,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return get(&p.Source.(*movieNode).Movie), nil
+		},
+	}
+}
+
+var categoryType = gql.NewObject(gql.ObjectConfig{
+	Name: "Category",
+	Fields: gql.Fields{
+		"id":   &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Category).ID, nil }},
+		"name": &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Category).Name, nil }},
+		"slug": &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Category).Slug, nil }},
+	},
+})
+
+var countryType = gql.NewObject(gql.ObjectConfig{
+	Name: "Country",
+	Fields: gql.Fields{
+		"id":   &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Country).ID, nil }},
+		"name": &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Country).Name, nil }},
+		"slug": &gql.Field{Type: gql.String, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(movies.Country).Slug, nil }},
+	},
+})
+
+func episodeField(get func(e movies.Episode) interface{}) *gql.Field {
+	return &gql.Field{
+		Type: gql.String,
+		Resolve: func(p gql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(movies.Episode)), nil
+		},
+	}
+}
+
+var episodeType = gql.NewObject(gql.ObjectConfig{
+	Name: "Episode",
+	Fields: gql.Fields{
+		"serverName": episodeField(func(e movies.Episode) interface{} { return e.ServerName }),
+		"name":       episodeField(func(e movies.Episode) interface{} { return e.Name }),
+		"slug":       episodeField(func(e movies.Episode) interface{} { return e.Slug }),
+		"filename":   episodeField(func(e movies.Episode) interface{} { return e.Filename }),
+		"linkEmbed":  episodeField(func(e movies.Episode) interface{} { return e.LinkEmbed }),
+		"linkM3u8":   episodeField(func(e movies.Episode) interface{} { return e.LinkM3U8 }),
+	},
+})
+
+var movieType = gql.NewObject(gql.ObjectConfig{
+	Name: "Movie",
+	Fields: gql.Fields{
+		"id":             movieField(gql.String, func(m *movies.Movie) interface{} { return m.ID }),
+		"name":           movieField(gql.String, func(m *movies.Movie) interface{} { return m.Name }),
+		"slug":           movieField(gql.String, func(m *movies.Movie) interface{} { return m.Slug }),
+		"originName":     movieField(gql.String, func(m *movies.Movie) interface{} { return m.OriginName }),
+		"content":        movieField(gql.String, func(m *movies.Movie) interface{} { return m.Content }),
+		"type":           movieField(gql.String, func(m *movies.Movie) interface{} { return m.Type }),
+		"status":         movieField(gql.String, func(m *movies.Movie) interface{} { return m.Status }),
+		"posterUrl":      movieField(gql.String, func(m *movies.Movie) interface{} { return m.PosterURL }),
+		"thumbUrl":       movieField(gql.String, func(m *movies.Movie) interface{} { return m.ThumbURL }),
+		"trailerUrl":     movieField(gql.String, func(m *movies.Movie) interface{} { return m.TrailerURL }),
+		"time":           movieField(gql.String, func(m *movies.Movie) interface{} { return m.Time }),
+		"episodeCurrent": movieField(gql.String, func(m *movies.Movie) interface{} { return m.EpisodeCurrent }),
+		"episodeTotal":   movieField(gql.String, func(m *movies.Movie) interface{} { return m.EpisodeTotal }),
+		"quality":        movieField(gql.String, func(m *movies.Movie) interface{} { return m.Quality }),
+		"lang":           movieField(gql.String, func(m *movies.Movie) interface{} { return m.Lang }),
+		"year":           movieField(gql.Int, func(m *movies.Movie) interface{} { return m.Year }),
+		"view":           movieField(gql.Int, func(m *movies.Movie) interface{} { return m.View }),
+		"chieuRap":       movieField(gql.Boolean, func(m *movies.Movie) interface{} { return m.ChieuRap }),
+		"subDocQuyen":    movieField(gql.Boolean, func(m *movies.Movie) interface{} { return m.SubDocQuyen }),
+		"actor":          movieField(gql.NewList(gql.String), func(m *movies.Movie) interface{} { return []string(m.Actor) }),
+		"director":       movieField(gql.NewList(gql.String), func(m *movies.Movie) interface{} { return []string(m.Director) }),
+		"tmdbVote":       movieField(gql.Float, func(m *movies.Movie) interface{} { return m.TMDB.VoteAverage }),
+		"modified": movieField(gql.String, func(m *movies.Movie) interface{} {
+			if m.Modified.Time.IsZero() {
+				return nil
+			}
+			return m.Modified.Time.Format(time.RFC3339)
+		}),
+		"categories": &gql.Field{
+			Type: gql.NewList(categoryType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*movieNode)
+				if node.Categories != nil {
+					return node.Categories, nil
+				}
+				return loadersFrom(p.Context).Categories.Load(node.ID), nil
+			},
+		},
+		"countries": &gql.Field{
+			Type: gql.NewList(countryType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*movieNode)
+				if node.Countries != nil {
+					return node.Countries, nil
+				}
+				return loadersFrom(p.Context).Countries.Load(node.ID), nil
+			},
+		},
+		"episodes": &gql.Field{
+			Type: gql.NewList(episodeType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*movieNode)
+				if node.Episodes != nil {
+					return node.Episodes, nil
+				}
+				return loadersFrom(p.Context).Episodes.Load(node.ID), nil
+			},
+		},
+	},
+})
+
+var paginationType = gql.NewObject(gql.ObjectConfig{
+	Name: "Pagination",
+	Fields: gql.Fields{
+		"currentPage":  &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).CurrentPage, nil }},
+		"itemsPerPage": &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).ItemsPerPage, nil }},
+		"totalCount":   &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).TotalCount, nil }},
+		"totalPages":   &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).TotalPages, nil }},
+		"nextPage":     &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).NextPage, nil }},
+		"previousPage": &gql.Field{Type: gql.Int, Resolve: func(p gql.ResolveParams) (interface{}, error) { return p.Source.(utils.Pagination).PreviousPage, nil }},
+	},
+})
+
+var movieListType = gql.NewObject(gql.ObjectConfig{
+	Name: "MovieList",
+	Fields: gql.Fields{
+		"items": &gql.Field{
+			Type: gql.NewList(movieType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				return p.Source.(*movieListNode).Items, nil
+			},
+		},
+		"pagination": &gql.Field{
+			Type: paginationType,
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				return p.Source.(*movieListNode).Pagination, nil
+			},
+		},
+	},
+})
+
+// listArgs are the filter arguments shared by every list query
+var listArgs = gql.FieldConfigArgument{
+	"page":      &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 1},
+	"sortField": &gql.ArgumentConfig{Type: gql.String, DefaultValue: "modified.time"},
+	"sortType":  &gql.ArgumentConfig{Type: gql.String, DefaultValue: "desc"},
+	"sortLang":  &gql.ArgumentConfig{Type: gql.String},
+	"category":  &gql.ArgumentConfig{Type: gql.String},
+	"country":   &gql.ArgumentConfig{Type: gql.String},
+	"year":      &gql.ArgumentConfig{Type: gql.Int},
+	"limit":     &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 20},
+}
+
+// withArgs copies listArgs and adds extra arguments
+func withArgs(extra gql.FieldConfigArgument) gql.FieldConfigArgument {
+	out := gql.FieldConfigArgument{}
+	for k, v := range listArgs {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
+func argString(p gql.ResolveParams, name string) string {
+	s, _ := p.Args[name].(string)
+	return s
+}
+
+func argInt(p gql.ResolveParams, name string) int {
+	i, _ := p.Args[name].(int)
+	return i
+}
+
+// movieFromItem converts a list entry into a Movie model; relations stay nil when upstream omits them
+func movieFromItem(item lib.MovieItem) *movieNode {
+	return &movieNode{Movie: movies.Movie{
+		ID:             item.ID,
+		Name:           item.Name,
+		Slug:           item.Slug,
+		OriginName:     item.OriginName,
+		Type:           item.Type,
+		PosterURL:      item.PosterURL,
+		ThumbURL:       item.ThumbURL,
+		SubDocQuyen:    item.SubDocQuyen,
+		ChieuRap:       item.ChieuRap,
+		Time:           item.Time,
+		EpisodeCurrent: item.EpisodeCurrent,
+		Quality:        item.Quality,
+		Lang:           item.Lang,
+		Year:           item.Year,
+		TMDB:           item.TMDB,
+		IMDB:           item.IMDB,
+		Modified:       item.Modified,
+		Categories:     item.Categories,
+		Countries:      item.Countries,
+	}}
+}
+
+func listNode(items []lib.MovieItem, pagination utils.Pagination) *movieListNode {
+	nodes := make([]*movieNode, 0, len(items))
+	for _, item := range items {
+		nodes = append(nodes, movieFromItem(item))
+	}
+	return &movieListNode{Items: nodes, Pagination: pagination}
+}
+
+var queryType = gql.NewObject(gql.ObjectConfig{
+	Name: "Query",
+	Fields: gql.Fields{
+		"movie": &gql.Field{
+			Type: movieType,
+			Args: gql.FieldConfigArgument{
+				"slug": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+			},
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				slug := argString(p, "slug")
+				if err := lib.ValidateSlug(slug); err != nil {
+					return nil, err
+				}
+				res, err := service.GetDetailsMovie(p.Context, slug)
+				if err != nil {
+					return nil, err
+				}
+				node := &movieNode{Movie: res.Movie, Episodes: []movies.Episode{}}
+				for _, group := range res.Episodes {
+					for _, ep := range group.ServerData {
+						ep.ServerName = group.ServerName
+						node.Episodes = append(node.Episodes, ep)
+					}
+				}
+				return node, nil
+			},
+		},
+		"newest": &gql.Field{
+			Type: movieListType,
+			Args: gql.FieldConfigArgument{
+				"page": &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 1},
+				"v":    &gql.ArgumentConfig{Type: gql.Int, DefaultValue: 1},
+			},
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				req := lib.NewestMoviesRequest{Page: argInt(p, "page"), V: argInt(p, "v")}
+				if err := lib.ValidateNewestMoviesRequest(&req); err != nil {
+					return nil, err
+				}
+				res, err := service.GetListNewestMovies(p.Context, req.Page, req.V)
+				if err != nil {
+					return nil, err
+				}
+				return listNode(res.Items, res.Pagination), nil
+			},
+		},
+		"movies": &gql.Field{
+			Type: movieListType,
+			Args: withArgs(gql.FieldConfigArgument{
+				"typeList": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+			}),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				req := lib.MovieListRequest{
+					TypeList:  argString(p, "typeList"),
+					Page:      argInt(p, "page"),
+					SortField: argString(p, "sortField"),
+					SortType:  argString(p, "sortType"),
+					SortLang:  argString(p, "sortLang"),
+					Category:  argString(p, "category"),
+					Country:   argString(p, "country"),
+					Year:      argInt(p, "year"),
+					Limit:     argInt(p, "limit"),
+				}
+				if err := lib.ValidateMovieListRequest(&req); err != nil {
+					return nil, err
+				}
+				res, err := service.GetMovieList(p.Context, req)
+				if err != nil {
+					return nil, err
+				}
+				return listNode(res.Items, res.Pagination), nil
+			},
+		},
+		"search": &gql.Field{
+			Type: movieListType,
+			Args: withArgs(gql.FieldConfigArgument{
+				"keyword": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+			}),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				req := lib.MovieSearchRequest{
+					Keyword:   argString(p, "keyword"),
+					Page:      argInt(p, "page"),
+					SortField: argString(p, "sortField"),
+					SortType:  argString(p, "sortType"),
+					SortLang:  argString(p, "sortLang"),
+					Category:  argString(p, "category"),
+					Country:   argString(p, "country"),
+					Year:      argInt(p, "year"),
+					Limit:     argInt(p, "limit"),
+				}
+				if err := lib.ValidateMovieSearchRequest(&req); err != nil {
+					return nil, err
+				}
+				res, err := service.GetSearchMovies(p.Context, req)
+				if err != nil {
+					return nil, err
+				}
+				return listNode(res.Items, res.Pagination), nil
+			},
+		},
+		"moviesByCategory": &gql.Field{
+			Type: movieListType,
+			Args: withArgs(nil),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				req := categoryRequest(p)
+				if err := lib.ValidateMoviesByCategoryRequest(&req); err != nil {
+					return nil, err
+				}
+				res, err := service.ListMoviesByCategory(p.Context, req)
+				if err != nil {
+					return nil, err
+				}
+				return listNode(res.Items, res.Pagination), nil
+			},
+		},
+		"moviesByCountry": &gql.Field{
+			Type: movieListType,
+			Args: withArgs(nil),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				req := categoryRequest(p)
+				if err := lib.ValidateMoviesByCountryRequest(&req); err != nil {
+					return nil, err
+				}
+				res, err := service.ListMoviesByCountry(p.Context, req)
+				if err != nil {
+					return nil, err
+				}
+				return listNode(res.Items, res.Pagination), nil
+			},
+		},
+		"categories": &gql.Field{
+			Type: gql.NewList(categoryType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				res, err := service.ListAllCategories(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				return res.Items, nil
+			},
+		},
+		"countries": &gql.Field{
+			Type: gql.NewList(countryType),
+			Resolve: func(p gql.ResolveParams) (interface{}, error) {
+				res, err := service.ListAllCountry(p.Context)
+				if err != nil {
+					return nil, err
+				}
+				return res.Items, nil
+			},
+		},
+	},
+})
+
+func categoryRequest(p gql.ResolveParams) lib.MoviesByCategoryRequest {
+	return lib.MoviesByCategoryRequest{
+		Category:  argString(p, "category"),
+		Page:      argInt(p, "page"),
+		SortField: argString(p, "sortField"),
+		SortType:  argString(p, "sortType"),
+		SortLang:  argString(p, "sortLang"),
+		Country:   argString(p, "country"),
+		Year:      argInt(p, "year"),
+		Limit:     argInt(p, "limit"),
+	}
+}
@@ -2,23 +2,25 @@ package movies
 
 import (
 	service "ani4s/src/modules/movies/services"
-	"net/http"
+	"ani4s/src/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 func ListCategories(c *gin.Context) {
-	res, err := service.ListAllCategories()
+	res, err := service.ListAllCategories(c.Request.Context())
 	if err != nil {
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	utils.RespondSuccess(c, res.Items, nil, &res.Meta)
 }
 
 func ListCountry(c *gin.Context) {
-	res, err := service.ListAllCountry()
+	res, err := service.ListAllCountry(c.Request.Context())
 	if err != nil {
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	utils.RespondSuccess(c, res.Items, nil, &res.Meta)
 }
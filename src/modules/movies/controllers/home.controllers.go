@@ -0,0 +1,22 @@
+package movies
+
+import (
+	service "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// homeCacheMaxAge is short so scheduled sections show up close to their start time
+const homeCacheMaxAge = time.Minute
+
+// GetHome returns every active homepage section in one response
+func GetHome(c *gin.Context) {
+	res, err := service.GetHome(c.Request.Context())
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondCacheable(c, res.Sections, nil, &res.Meta, homeCacheMaxAge)
+}
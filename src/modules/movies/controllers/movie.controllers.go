@@ -3,67 +3,110 @@ package movies
 import (
 	lib "ani4s/src/modules/movies/lib"
 	movies "ani4s/src/modules/movies/services"
-	"net/http"
+	"ani4s/src/utils"
+	"context"
 
 	"github.com/gin-gonic/gin"
 )
 
 func NewestUpdateMovies(c *gin.Context) {
-	var req struct {
-		Page int `json:"page"`
-		V    int `json:"v"`
+	var req lib.NewestMoviesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
 	}
-	err := c.BindJSON(&req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := lib.ValidateNewestMoviesRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
 		return
 	}
 
-	res, err := movies.GetListNewestMovies(req.Page, req.V)
+	res, err := movies.GetListNewestMovies(c.Request.Context(), req.Page, req.V)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	utils.RespondSuccess(c, res.Items, &res.Pagination, &res.Meta)
 }
 
 func GetMovieDetails(c *gin.Context) {
 	slug := c.Param("slug")
+	if err := lib.ValidateSlug(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
 
-	res, err := movies.GetDetailsMovie(slug)
+	res, err := movies.GetDetailsMovie(c.Request.Context(), slug)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	// The view is counted after the response, so it must outlive the request context
+	go movies.RecordView(context.WithoutCancel(c.Request.Context()), slug, c.ClientIP())
+	utils.RespondSuccess(c, res.MovieDetail, nil, &res.Meta)
 }
 
 func ListMoviesByCategory(c *gin.Context) {
 	var req lib.MoviesByCategoryRequest
-	err := c.BindJSON(&req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	moviesByCategory(c, req, false)
+}
+
+// ListMoviesByCategoryQuery is the GET variant of ListMoviesByCategory, bound from the query string
+func ListMoviesByCategoryQuery(c *gin.Context) {
+	var req lib.MoviesByCategoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	moviesByCategory(c, req, true)
+}
+
+func moviesByCategory(c *gin.Context, req lib.MoviesByCategoryRequest, cacheable bool) {
+	if err := lib.ValidateMoviesByCategoryRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
 	}
 
-	res, err := movies.ListMoviesByCategory(req)
+	res, err := movies.ListMoviesByCategory(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	respondList(c, res.Items, &res.Pagination, &res.Meta, cacheable)
 }
 
 func ListMoviesByCountry(c *gin.Context) {
 	var req lib.MoviesByCategoryRequest
-	err := c.BindJSON(&req)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	moviesByCountry(c, req, false)
+}
+
+// ListMoviesByCountryQuery is the GET variant of ListMoviesByCountry, bound from the query string
+func ListMoviesByCountryQuery(c *gin.Context) {
+	var req lib.MoviesByCategoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	moviesByCountry(c, req, true)
+}
+
+func moviesByCountry(c *gin.Context, req lib.MoviesByCategoryRequest, cacheable bool) {
+	if err := lib.ValidateMoviesByCountryRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
 	}
 
-	res, err := movies.ListMoviesByCountry(req)
+	res, err := movies.ListMoviesByCountry(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, res)
+	respondList(c, res.Items, &res.Pagination, &res.Meta, cacheable)
 }
@@ -3,93 +3,92 @@ package movies
 import (
 	"ani4s/src/modules/movies/lib"
 	service "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
 	"github.com/gin-gonic/gin"
-	"net/http"
+	"time"
 )
 
+// listCacheMaxAge is how long browsers and CDNs may keep GET list/search responses
+const listCacheMaxAge = 5 * time.Minute
+
 func GetMovieList(c *gin.Context) {
 	var req movies.MovieListRequest
 
 	// Bind JSON request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   "Invalid request parameters: " + err.Error(),
-		})
+		utils.RespondServiceError(c, utils.BindError(err))
 		return
 	}
+	movieList(c, req, false)
+}
 
-	// Set default values
-	if req.Page <= 0 {
-		req.Page = 1
-	}
-	if req.SortField == "" {
-		req.SortField = "modified.time"
-	}
-	if req.SortType == "" {
-		req.SortType = "desc"
-	}
-	if req.Limit <= 0 || req.Limit > 64 {
-		req.Limit = 20
-	}
-
-	// Validate sort_field
-	validSortFields := map[string]bool{
-		"modified.time": true,
-		"_id":           true,
-		"year":          true,
-	}
-	if !validSortFields[req.SortField] {
-		req.SortField = "modified.time"
-	}
-
-	// Validate sort_type
-	if req.SortType != "asc" && req.SortType != "desc" {
-		req.SortType = "desc"
-	}
+// GetMovieListQuery is the GET variant of GetMovieList, bound from the query string
+func GetMovieListQuery(c *gin.Context) {
+	var req movies.MovieListRequest
 
-	// Validate sort_lang
-	if req.SortLang != "" {
-		validSortLang := map[string]bool{
-			"vietsub":     true,
-			"thuyet-minh": true,
-			"long-tieng":  true,
-		}
-		if !validSortLang[req.SortLang] {
-			req.SortLang = ""
-		}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
 	}
+	movieList(c, req, true)
+}
 
-	// Validate year
-	if req.Year != 0 && (req.Year < 1970 || req.Year > 2025) {
-		req.Year = 0
+func movieList(c *gin.Context, req movies.MovieListRequest, cacheable bool) {
+	// Apply defaults and validate
+	if err := movies.ValidateMovieListRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
 	}
 
 	// Call service
-	res, err := service.GetMovieList(req)
+	res, err := service.GetMovieList(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"error":   "Failed to fetch movie list: " + err.Error(),
-		})
+		utils.RespondServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, res)
+	respondList(c, res.Items, &res.Pagination, &res.Meta, cacheable)
 }
 
 func SearchMovies(c *gin.Context) {
 	var req movies.MovieSearchRequest
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	searchMovies(c, req, false)
+}
+
+// SearchMoviesQuery is the GET variant of SearchMovies, bound from the query string
+func SearchMoviesQuery(c *gin.Context) {
+	var req movies.MovieSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	searchMovies(c, req, true)
+}
+
+func searchMovies(c *gin.Context, req movies.MovieSearchRequest, cacheable bool) {
+	if err := movies.ValidateMovieSearchRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
 		return
 	}
 
-	result, err := service.GetSearchMovies(req)
+	result, err := service.GetSearchMovies(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.RespondServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	respondList(c, result.Items, &result.Pagination, &result.Meta, cacheable)
+}
+
+// respondList writes a list envelope, adding Cache-Control/ETag for GET variants
+func respondList(c *gin.Context, items []movies.MovieItem, pagination *utils.Pagination, meta *utils.Meta, cacheable bool) {
+	if cacheable {
+		utils.RespondCacheable(c, items, pagination, meta, listCacheMaxAge)
+		return
+	}
+	utils.RespondSuccess(c, items, pagination, meta)
 }
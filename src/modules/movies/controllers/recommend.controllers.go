@@ -0,0 +1,55 @@
+package movies
+
+import (
+	"ani4s/src/middlewares"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSimilarMovies returns the titles most similar to a movie
+func GetSimilarMovies(c *gin.Context) {
+	slug := c.Param("slug")
+	var req lib.RecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	if err := lib.ValidateSlug(slug); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	if err := lib.ValidateRecommendationRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	res, err := movies.GetSimilar(c.Request.Context(), slug, req.Limit)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondCacheable(c, res.Items, nil, &res.Meta, listCacheMaxAge)
+}
+
+// GetUserRecommendations returns "Because you watched" rows for the user authenticated by RequireUser
+func GetUserRecommendations(c *gin.Context) {
+	var req lib.RecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	if err := lib.ValidateRecommendationRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	res, err := movies.GetRecommendations(c.Request.Context(), middlewares.UserID(c), req.Limit)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, res.Sections, nil, &res.Meta)
+}
@@ -0,0 +1,51 @@
+package movies
+
+import (
+	"ani4s/src/middlewares"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrending returns a page of the day, week or all-time ranking, optionally by category or country
+func GetTrending(c *gin.Context) {
+	var req lib.TrendingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	if err := lib.ValidateTrendingRequest(&req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	res, err := movies.GetTrending(c.Request.Context(), req)
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondCacheable(c, res.Items, &res.Pagination, &res.Meta, listCacheMaxAge)
+}
+
+// PlayEpisode records that an episode started playing, feeding the trending rankings
+func PlayEpisode(c *gin.Context) {
+	slug := c.Param("slug")
+	var req lib.PlayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondServiceError(c, utils.BindError(err))
+		return
+	}
+	if err := lib.ValidatePlayRequest(slug, &req); err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+
+	recorded, err := movies.RecordPlay(c.Request.Context(), slug, req.Episode, c.ClientIP(), middlewares.UserID(c))
+	if err != nil {
+		utils.RespondServiceError(c, err)
+		return
+	}
+	utils.RespondSuccess(c, lib.PlayResponse{Slug: slug, Episode: req.Episode, Recorded: recorded}, nil, nil)
+}
@@ -1,36 +1,62 @@
 package movies
 
 type MovieListRequest struct {
-	TypeList  string `json:"type_list" binding:"required"`
-	Page      int    `json:"page"`
-	SortField string `json:"sort_field"`
-	SortType  string `json:"sort_type"`
-	SortLang  string `json:"sort_lang"`
-	Category  string `json:"category"`
-	Country   string `json:"country"`
-	Year      int    `json:"year"`
-	Limit     int    `json:"limit"`
+	TypeList  string `json:"type_list" form:"type_list" binding:"required"`
+	Page      int    `json:"page" form:"page"`
+	SortField string `json:"sort_field" form:"sort_field"`
+	SortType  string `json:"sort_type" form:"sort_type"`
+	SortLang  string `json:"sort_lang" form:"sort_lang"`
+	Category  string `json:"category" form:"category"`
+	Country   string `json:"country" form:"country"`
+	Year      int    `json:"year" form:"year"`
+	Limit     int    `json:"limit" form:"limit"`
 }
 
 type MovieSearchRequest struct {
-	Keyword   string `json:"keyword"`
-	Page      int    `json:"page"`
-	SortField string `json:"sort_field"`
-	SortType  string `json:"sort_type"`
-	SortLang  string `json:"sort_lang"`
-	Category  string `json:"category"`
-	Country   string `json:"country"`
-	Year      int    `json:"year"`
-	Limit     int    `json:"limit"`
+	Keyword   string `json:"keyword" form:"keyword"`
+	Page      int    `json:"page" form:"page"`
+	SortField string `json:"sort_field" form:"sort_field"`
+	SortType  string `json:"sort_type" form:"sort_type"`
+	SortLang  string `json:"sort_lang" form:"sort_lang"`
+	Category  string `json:"category" form:"category"`
+	Country   string `json:"country" form:"country"`
+	Year      int    `json:"year" form:"year"`
+	Limit     int    `json:"limit" form:"limit"`
 }
 
 type MoviesByCategoryRequest struct {
-	Category  string `json:"category"`
-	Page      int    `json:"page"`
-	SortField string `json:"sort_field"`
-	SortType  string `json:"sort_type"`
-	SortLang  string `json:"sort_lang"`
-	Country   string `json:"country"`
-	Year      int    `json:"year"`
-	Limit     int    `json:"limit"`
+	Category  string `json:"category" form:"category"`
+	Page      int    `json:"page" form:"page"`
+	SortField string `json:"sort_field" form:"sort_field"`
+	SortType  string `json:"sort_type" form:"sort_type"`
+	SortLang  string `json:"sort_lang" form:"sort_lang"`
+	Country   string `json:"country" form:"country"`
+	Year      int    `json:"year" form:"year"`
+	Limit     int    `json:"limit" form:"limit"`
+}
+
+type NewestMoviesRequest struct {
+	Page int `json:"page" form:"page"`
+	V    int `json:"v" form:"v"`
+}
+
+// TrendingRequest selects a trending ranking; Category and Country are mutually exclusive
+type TrendingRequest struct {
+	Window   string `json:"window" form:"window"`
+	Category string `json:"category" form:"category"`
+	Country  string `json:"country" form:"country"`
+	Page     int    `json:"page" form:"page"`
+	Limit    int    `json:"limit" form:"limit"`
+}
+
+// PlayRequest reports that an episode of a movie started playing. The movie joins the
+// watch history of the user of the request's token, if any.
+type PlayRequest struct {
+	Episode string `json:"episode" binding:"required"`
+	Server  string `json:"server"`
+}
+
+// RecommendationRequest pages the similar titles of a movie or the recommendations of a user
+type RecommendationRequest struct {
+	Limit int `json:"limit" form:"limit"`
 }
@@ -0,0 +1,123 @@
+package movies
+
+import (
+	models "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+)
+
+// MovieItem is a single movie entry of a list or search result
+type MovieItem struct {
+	ID             string            `json:"_id"`
+	Name           string            `json:"name"`
+	Slug           string            `json:"slug"`
+	OriginName     string            `json:"origin_name"`
+	Type           string            `json:"type"`
+	PosterURL      string            `json:"poster_url"`
+	ThumbURL       string            `json:"thumb_url"`
+	SubDocQuyen    bool              `json:"sub_docquyen"`
+	ChieuRap       bool              `json:"chieurap"`
+	Time           string            `json:"time"`
+	EpisodeCurrent string            `json:"episode_current"`
+	Quality        string            `json:"quality"`
+	Lang           string            `json:"lang"`
+	Year           int               `json:"year"`
+	Categories     []models.Category `json:"category"`
+	Countries      []models.Country  `json:"country"`
+	TMDB           models.TMDBInfo   `json:"tmdb"`
+	IMDB           models.IMDBInfo   `json:"imdb"`
+	Modified       models.Timestamp  `json:"modified"`
+}
+
+// MovieListResponse is returned by the newest, list, category and country services
+type MovieListResponse struct {
+	Items      []MovieItem      `json:"items"`
+	Pagination utils.Pagination `json:"pagination"`
+	Meta       utils.Meta       `json:"meta"`
+}
+
+// MovieSearchResponse is returned by the search service
+type MovieSearchResponse struct {
+	Keyword    string           `json:"keyword"`
+	Items      []MovieItem      `json:"items"`
+	Pagination utils.Pagination `json:"pagination"`
+	Meta       utils.Meta       `json:"meta"`
+}
+
+// MovieDetail is the data block of a movie details response
+type MovieDetail struct {
+	Movie    models.Movie          `json:"movie"`
+	Episodes []models.EpisodeGroup `json:"episodes"`
+}
+
+// MovieDetailResponse is returned by the details service
+type MovieDetailResponse struct {
+	MovieDetail
+	Meta utils.Meta `json:"meta"`
+}
+
+// CategoryListResponse is returned by the categories service
+type CategoryListResponse struct {
+	Items []models.Category `json:"items"`
+	Meta  utils.Meta        `json:"meta"`
+}
+
+// CountryListResponse is returned by the countries service
+type CountryListResponse struct {
+	Items []models.Country `json:"items"`
+	Meta  utils.Meta       `json:"meta"`
+}
+
+// HomeSection is one collection rendered on the homepage
+type HomeSection struct {
+	Slug  string      `json:"slug"`
+	Title string      `json:"title"`
+	Kind  string      `json:"kind"`
+	Items []MovieItem `json:"items"`
+}
+
+// HomeResponse is returned by the home service
+type HomeResponse struct {
+	Sections []HomeSection `json:"sections"`
+	Meta     utils.Meta    `json:"meta"`
+}
+
+// TrendingItem is a movie of a trending ranking with its decayed score
+type TrendingItem struct {
+	MovieItem
+	Score float64 `json:"score"`
+}
+
+// TrendingResponse is returned by the trending service
+type TrendingResponse struct {
+	Window     string           `json:"window"`
+	Items      []TrendingItem   `json:"items"`
+	Pagination utils.Pagination `json:"pagination"`
+	Meta       utils.Meta       `json:"meta"`
+}
+
+// PlayResponse tells whether a play was counted (repeats from the same client are not)
+type PlayResponse struct {
+	Slug     string `json:"slug"`
+	Episode  string `json:"episode"`
+	Recorded bool   `json:"recorded"`
+}
+
+// SimilarResponse is returned by the similar titles service
+type SimilarResponse struct {
+	Slug  string      `json:"slug"`
+	Items []MovieItem `json:"items"`
+	Meta  utils.Meta  `json:"meta"`
+}
+
+// RecommendationSection is a "Because you watched" row
+type RecommendationSection struct {
+	Because MovieItem   `json:"because"`
+	Items   []MovieItem `json:"items"`
+}
+
+// RecommendationsResponse is returned by the per-user recommendations service
+type RecommendationsResponse struct {
+	UserID   uint                    `json:"user_id"`
+	Sections []RecommendationSection `json:"sections"`
+	Meta     utils.Meta              `json:"meta"`
+}
@@ -0,0 +1,255 @@
+package movies
+
+import (
+	models "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit     = 20
+	MaxLimit         = 64
+	MinYear          = 1970
+	MaxKeywordLength = 100
+	MaxNewestVersion = 3
+
+	MaxCollectionSlugs = 100
+)
+
+var (
+	slugPattern     = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	ValidSortFields = []string{"modified.time", "_id", "year"}
+	ValidSortTypes  = []string{"asc", "desc"}
+	ValidSortLangs  = []string{"vietsub", "thuyet-minh", "long-tieng"}
+	TrendingWindows = []string{"day", "week", "all"}
+)
+
+// MaxYear is the latest release year accepted in filters (next year, for announced titles)
+func MaxYear() int {
+	return time.Now().Year() + 1
+}
+
+// ValidateSlug checks a movie slug taken from the path
+func ValidateSlug(slug string) error {
+	v := &utils.ValidationError{}
+	validateSlug(v, "slug", slug, true)
+	return v.OrNil()
+}
+
+// ValidateNewestMoviesRequest applies defaults and checks the newest list request
+func ValidateNewestMoviesRequest(req *NewestMoviesRequest) error {
+	v := &utils.ValidationError{}
+	validatePage(v, &req.Page)
+	if req.V == 0 {
+		req.V = 1
+	}
+	if req.V < 1 || req.V > MaxNewestVersion {
+		v.Add("v", fmt.Sprintf("must be between 1 and %d", MaxNewestVersion))
+	}
+	return v.OrNil()
+}
+
+// ValidateMovieListRequest applies defaults and checks a list request
+func ValidateMovieListRequest(req *MovieListRequest) error {
+	v := &utils.ValidationError{}
+	validateSlug(v, "type_list", req.TypeList, true)
+	validatePage(v, &req.Page)
+	validateLimit(v, &req.Limit)
+	validateSort(v, &req.SortField, &req.SortType, req.SortLang)
+	validateSlug(v, "category", req.Category, false)
+	validateSlug(v, "country", req.Country, false)
+	validateYear(v, req.Year)
+	return v.OrNil()
+}
+
+// ValidateMovieSearchRequest applies defaults and checks a search request
+func ValidateMovieSearchRequest(req *MovieSearchRequest) error {
+	v := &utils.ValidationError{}
+	req.Keyword = strings.TrimSpace(req.Keyword)
+	switch {
+	case req.Keyword == "":
+		v.Add("keyword", "is required")
+	case len([]rune(req.Keyword)) > MaxKeywordLength:
+		v.Add("keyword", fmt.Sprintf("must be at most %d characters", MaxKeywordLength))
+	}
+	validatePage(v, &req.Page)
+	validateLimit(v, &req.Limit)
+	validateSort(v, &req.SortField, &req.SortType, req.SortLang)
+	validateSlug(v, "category", req.Category, false)
+	validateSlug(v, "country", req.Country, false)
+	validateYear(v, req.Year)
+	return v.OrNil()
+}
+
+// ValidateMoviesByCategoryRequest applies defaults and checks a category listing request
+func ValidateMoviesByCategoryRequest(req *MoviesByCategoryRequest) error {
+	return validateBrowseRequest(req, "category")
+}
+
+// ValidateMoviesByCountryRequest applies defaults and checks a country listing request
+func ValidateMoviesByCountryRequest(req *MoviesByCategoryRequest) error {
+	return validateBrowseRequest(req, "country")
+}
+
+// validateBrowseRequest checks a MoviesByCategoryRequest where required names the mandatory slug
+func validateBrowseRequest(req *MoviesByCategoryRequest, required string) error {
+	v := &utils.ValidationError{}
+	validateSlug(v, "category", req.Category, required == "category")
+	validateSlug(v, "country", req.Country, required == "country")
+	validatePage(v, &req.Page)
+	validateLimit(v, &req.Limit)
+	validateSort(v, &req.SortField, &req.SortType, req.SortLang)
+	validateYear(v, req.Year)
+	return v.OrNil()
+}
+
+// ValidateTrendingRequest applies defaults and checks a trending request
+func ValidateTrendingRequest(req *TrendingRequest) error {
+	v := &utils.ValidationError{}
+	if req.Window == "" {
+		req.Window = "week"
+	}
+	if !contains(TrendingWindows, req.Window) {
+		v.Add("window", "must be one of "+strings.Join(TrendingWindows, ", "))
+	}
+	validateSlug(v, "category", req.Category, false)
+	validateSlug(v, "country", req.Country, false)
+	if req.Category != "" && req.Country != "" {
+		v.Add("country", "cannot be combined with category")
+	}
+	validatePage(v, &req.Page)
+	validateLimit(v, &req.Limit)
+	return v.OrNil()
+}
+
+// ValidatePlayRequest checks a play event
+func ValidatePlayRequest(slug string, req *PlayRequest) error {
+	v := &utils.ValidationError{}
+	validateSlug(v, "slug", slug, true)
+	req.Episode = strings.TrimSpace(req.Episode)
+	if req.Episode == "" {
+		v.Add("episode", "is required")
+	} else if len(req.Episode) > 255 {
+		v.Add("episode", "must be at most 255 characters")
+	}
+	if len(req.Server) > 255 {
+		v.Add("server", "must be at most 255 characters")
+	}
+	return v.OrNil()
+}
+
+// ValidateRecommendationRequest applies defaults and checks a similar / recommendations request
+func ValidateRecommendationRequest(req *RecommendationRequest) error {
+	v := &utils.ValidationError{}
+	validateLimit(v, &req.Limit)
+	return v.OrNil()
+}
+
+// ValidateCollection applies defaults and checks a collection before it is saved
+func ValidateCollection(c *models.Collection) error {
+	v := &utils.ValidationError{}
+	validateSlug(v, "slug", c.Slug, true)
+	c.Title = strings.TrimSpace(c.Title)
+	if c.Title == "" {
+		v.Add("title", "is required")
+	}
+	validateLimit(v, &c.Limit)
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		v.Add("ends_at", "must be after starts_at")
+	}
+
+	switch c.Kind {
+	case models.CollectionManual:
+		if len(c.Slugs) == 0 || len(c.Slugs) > MaxCollectionSlugs {
+			v.Add("slugs", fmt.Sprintf("must list between 1 and %d movies", MaxCollectionSlugs))
+		}
+		for i, slug := range c.Slugs {
+			validateSlug(v, fmt.Sprintf("slugs[%d]", i), slug, true)
+		}
+		c.Filter = nil
+	case models.CollectionQuery:
+		if c.Filter == nil {
+			v.Add("filter", "is required")
+			break
+		}
+		f := c.Filter
+		if f.TypeList == "" && f.Category == "" && f.Country == "" {
+			v.Add("filter", "set type_list, category or country")
+		}
+		validateSlug(v, "filter.type_list", f.TypeList, false)
+		validateSlug(v, "filter.category", f.Category, false)
+		validateSlug(v, "filter.country", f.Country, false)
+		validateSort(v, &f.SortField, &f.SortType, f.SortLang)
+		validateYear(v, f.Year)
+		c.Slugs = nil
+	default:
+		v.Add("kind", "must be one of "+models.CollectionManual+", "+models.CollectionQuery)
+	}
+	return v.OrNil()
+}
+
+func validateSlug(v *utils.ValidationError, field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.Add(field, "is required")
+		}
+		return
+	}
+	if len(value) > 255 || !slugPattern.MatchString(value) {
+		v.Add(field, "must be a slug (lowercase letters, digits and single dashes)")
+	}
+}
+
+func validatePage(v *utils.ValidationError, page *int) {
+	if *page == 0 {
+		*page = 1
+	}
+	if *page < 1 {
+		v.Add("page", "must be at least 1")
+	}
+}
+
+func validateLimit(v *utils.ValidationError, limit *int) {
+	if *limit == 0 {
+		*limit = DefaultLimit
+	}
+	if *limit < 1 || *limit > MaxLimit {
+		v.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxLimit))
+	}
+}
+
+func validateSort(v *utils.ValidationError, field, sortType *string, lang string) {
+	if *field == "" {
+		*field = "modified.time"
+	}
+	if !contains(ValidSortFields, *field) {
+		v.Add("sort_field", "must be one of "+strings.Join(ValidSortFields, ", "))
+	}
+	if *sortType == "" {
+		*sortType = "desc"
+	}
+	if !contains(ValidSortTypes, *sortType) {
+		v.Add("sort_type", "must be one of "+strings.Join(ValidSortTypes, ", "))
+	}
+	if lang != "" && !contains(ValidSortLangs, lang) {
+		v.Add("sort_lang", "must be one of "+strings.Join(ValidSortLangs, ", "))
+	}
+}
+
+func validateYear(v *utils.ValidationError, year int) {
+	if year != 0 && (year < MinYear || year > MaxYear()) {
+		v.Add("year", fmt.Sprintf("must be between %d and %d", MinYear, MaxYear()))
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
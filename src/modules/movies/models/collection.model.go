@@ -0,0 +1,54 @@
+package movies
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	CollectionManual = "manual" // hand-picked slugs, shown in the given order
+	CollectionQuery  = "query"  // a saved listing query resolved when the home is built
+)
+
+// CollectionFilter is a saved listing query. TypeList selects /danh-sach; without it
+// Category (the-loai) or Country (quoc-gia) is listed and the other one filters.
+type CollectionFilter struct {
+	TypeList  string `json:"type_list,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Country   string `json:"country,omitempty"`
+	Year      int    `json:"year,omitempty"`
+	SortLang  string `json:"sort_lang,omitempty"`
+	SortField string `json:"sort_field,omitempty"`
+	SortType  string `json:"sort_type,omitempty"`
+}
+
+// Collection is an admin-curated homepage section. Enabled sections are shown by
+// ascending Position while now is inside [StartsAt, EndsAt); nil bounds are open.
+type Collection struct {
+	Slug      string            `json:"slug" gorm:"primaryKey;type:varchar(255)"`
+	Title     string            `json:"title"`
+	Kind      string            `json:"kind" gorm:"type:varchar(16)"`
+	Slugs     pq.StringArray    `json:"slugs" gorm:"type:text[]"`
+	Filter    *CollectionFilter `json:"filter,omitempty" gorm:"serializer:json;type:jsonb"`
+	Limit     int               `json:"limit"`
+	Position  int               `json:"position"`
+	Enabled   bool              `json:"enabled"`
+	StartsAt  *time.Time        `json:"starts_at"`
+	EndsAt    *time.Time        `json:"ends_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ActiveAt reports whether the collection is shown at t
+func (c *Collection) ActiveAt(t time.Time) bool {
+	if !c.Enabled {
+		return false
+	}
+	if c.StartsAt != nil && t.Before(*c.StartsAt) {
+		return false
+	}
+	if c.EndsAt != nil && !t.Before(*c.EndsAt) {
+		return false
+	}
+	return true
+}
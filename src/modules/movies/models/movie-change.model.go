@@ -0,0 +1,23 @@
+package movies
+
+import "time"
+
+// Change kinds recorded in MovieChange.Kind
+const (
+	ChangeCreated        = "created"
+	ChangeUpdated        = "updated"
+	ChangeEpisodeAdded   = "episode_added"
+	ChangeEpisodeUpdated = "episode_updated"
+	ChangeEpisodeRemoved = "episode_removed"
+)
+
+// MovieChange is one entry of the change log written when a movie is synced from upstream
+type MovieChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MovieID   string    `json:"movie_id" gorm:"index;type:varchar(64);not null"`
+	Kind      string    `json:"kind" gorm:"type:varchar(32)"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value" gorm:"type:text"`
+	NewValue  string    `json:"new_value" gorm:"type:text"`
+	ChangedAt time.Time `json:"changed_at" gorm:"index"`
+}
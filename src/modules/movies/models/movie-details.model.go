@@ -1,7 +1,5 @@
 package movies
 
-import "gorm.io/gorm"
-
 type MovieDetails struct {
 	Status   bool           `json:"status" gorm:"-"`
 	Msg      string         `json:"msg" gorm:"-"`
@@ -16,19 +14,12 @@ type EpisodeGroup struct {
 
 type Episode struct {
 	ID         uint   `json:"id" gorm:"primaryKey"`
-	MovieID    string `json:"-" gorm:"not null;"`
+	MovieID    string `json:"-" gorm:"not null;uniqueIndex:idx_episodes_movie_server_slug,priority:1"`
 	Movie      Movie  `json:"-" gorm:"foreignKey:MovieID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
-	ServerName string `json:"-" json:"server_name"`
+	ServerName string `json:"-" json:"server_name" gorm:"uniqueIndex:idx_episodes_movie_server_slug,priority:2;type:varchar(255)"`
 	Name       string `json:"name"`
-	Slug       string `json:"slug" gorm:"uniqueIndex;type:varchar(255)"`
+	Slug       string `json:"slug" gorm:"uniqueIndex:idx_episodes_movie_server_slug,priority:3;type:varchar(255)"`
 	Filename   string `json:"filename"`
 	LinkEmbed  string `json:"link_embed"`
 	LinkM3U8   string `json:"link_m3u8"`
 }
-
-func MigrateMovieDetails(db *gorm.DB) error {
-	if err := db.AutoMigrate(&Episode{}); err != nil {
-		return err
-	}
-	return nil
-}
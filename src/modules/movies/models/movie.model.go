@@ -1,10 +1,10 @@
 package movies
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
-	"gorm.io/gorm"
 )
 
 type Movie struct {
@@ -35,8 +35,8 @@ type Movie struct {
 
 	TMDB     TMDBInfo  `json:"tmdb" gorm:"embedded"`
 	IMDB     IMDBInfo  `json:"imdb" gorm:"embedded"`
-	Created  Timestamp `json:"created" gorm:"embedded"`
-	Modified Timestamp `json:"modified" gorm:"embedded"`
+	Created  Timestamp `json:"created" gorm:"embedded;embeddedPrefix:created_"`
+	Modified Timestamp `json:"modified" gorm:"embedded;embeddedPrefix:modified_"`
 
 	Categories []Category `json:"category" gorm:"many2many:movie_categories;"`
 	Countries  []Country  `json:"country" gorm:"many2many:movie_countries;"`
@@ -61,15 +61,44 @@ type Timestamp struct {
 type Category struct {
 	ID   string `json:"id" gorm:"primaryKey"`
 	Name string `json:"name" gorm:"index"`
-	Slug string `json:"slug" gorm:"index"`
+	Slug string `json:"slug" gorm:"uniqueIndex:idx_categories_slug_unique;type:varchar(255)"`
 }
 
 type Country struct {
 	ID   string `json:"id" gorm:"primaryKey"`
 	Name string `json:"name" gorm:"index"`
-	Slug string `json:"slug" gorm:"index"`
+	Slug string `json:"slug" gorm:"uniqueIndex:idx_countries_slug_unique;type:varchar(255)"`
 }
 
-func MigrateMovies(db *gorm.DB) error {
-	return db.AutoMigrate(&Movie{})
+// taxonomyJSON accepts both `id` (movie details) and `_id` (/the-loai, /quoc-gia)
+type taxonomyJSON struct {
+	ID       string `json:"id"`
+	LegacyID string `json:"_id"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+}
+
+func (t taxonomyJSON) id() string {
+	if t.ID != "" {
+		return t.ID
+	}
+	return t.LegacyID
+}
+
+func (c *Category) UnmarshalJSON(b []byte) error {
+	var raw taxonomyJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*c = Category{ID: raw.id(), Name: raw.Name, Slug: raw.Slug}
+	return nil
+}
+
+func (c *Country) UnmarshalJSON(b []byte) error {
+	var raw taxonomyJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*c = Country{ID: raw.id(), Name: raw.Name, Slug: raw.Slug}
+	return nil
 }
@@ -0,0 +1,26 @@
+package movies
+
+import "time"
+
+// CategoryOverride renames or hides a category everywhere it is served.
+// An empty Name keeps the upstream name.
+type CategoryOverride struct {
+	Slug      string    `json:"slug" gorm:"primaryKey;type:varchar(255)"`
+	Name      string    `json:"name"`
+	Hidden    bool      `json:"hidden"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MovieOverride replaces fields of a movie or hides it (DMCA / takedown).
+// Empty fields keep the upstream values.
+type MovieOverride struct {
+	Slug      string    `json:"slug" gorm:"primaryKey;type:varchar(255)"`
+	Name      string    `json:"name"`
+	PosterURL string    `json:"poster_url"`
+	ThumbURL  string    `json:"thumb_url"`
+	Content   string    `json:"content"`
+	Hidden    bool      `json:"hidden"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
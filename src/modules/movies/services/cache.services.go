@@ -0,0 +1,176 @@
+package movies
+
+import (
+	"ani4s/src/cache"
+	"ani4s/src/config"
+	"ani4s/src/metrics"
+	"ani4s/src/tracing"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CacheTags lists the tag sets that index cached payloads (stored as "<tag>:cached_keys")
+var CacheTags = []string{
+	"movie_list",
+	"movie_search",
+	"movie_category",
+	"movie_country",
+	"movie_newest",
+	"movie_details",
+	"trending",
+	"home",
+	"movie_similar",
+}
+
+// TagStat summarises one cache tag
+type TagStat struct {
+	Tag   string `json:"tag"`
+	Keys  int64  `json:"keys"`  // members of the tag set
+	Live  int64  `json:"live"`  // members whose payload still exists
+	Bytes int64  `json:"bytes"` // memory used by the live payloads
+}
+
+// tagTTL is the lifetime of the payloads of a tag and of the tag set itself
+type tagTTL struct {
+	payload time.Duration
+	tag     time.Duration
+}
+
+// tagTTLs returns the TTLs of tag from the cache settings. A tag set lives as long as its
+// payloads so a flush reaches all of them; trending and home keep theirs for at least an
+// hour, and similar lists only live until their next rebuild.
+func tagTTLs(tag string) tagTTL {
+	ttl, jobs := config.App.Cache.TTL, config.App.Jobs
+	switch tag {
+	case "movie_list":
+		return tagTTL{ttl.List, ttl.List}
+	case "movie_search":
+		return tagTTL{ttl.Search, ttl.Search}
+	case "movie_category":
+		return tagTTL{ttl.Category, ttl.Category}
+	case "movie_country":
+		return tagTTL{ttl.Country, ttl.Country}
+	case "movie_newest":
+		return tagTTL{ttl.Newest, ttl.Newest}
+	case "movie_details":
+		return tagTTL{ttl.Details, ttl.Details}
+	case "trending":
+		return tagTTL{jobs.TrendingInterval, max(jobs.TrendingInterval, time.Hour)}
+	case "home":
+		return tagTTL{ttl.Home, max(ttl.Home, time.Hour)}
+	case "movie_similar":
+		return tagTTL{jobs.RecommendInterval, jobs.RecommendInterval}
+	}
+	return tagTTL{}
+}
+
+// TagKey returns the Redis set holding the keys of tag; full set names are accepted too
+func TagKey(tag string) string {
+	return strings.TrimSuffix(tag, ":cached_keys") + ":cached_keys"
+}
+
+// getCached decodes the payload stored under cacheKey into v and reports whether it was a hit
+func getCached(ctx context.Context, cacheKey string, v interface{}) bool {
+	ctx, span := tracing.StartClient(ctx, "cache.get",
+		attribute.String("cache.key", cacheKey),
+		attribute.String("cache.tag", metrics.CacheTag(cacheKey)),
+	)
+	cached, err := config.Cache.Get(ctx, cacheKey)
+	hit := err == nil && len(cached) > 0 && cache.Unmarshal(cached, v) == nil
+	metrics.ObserveCache(cacheKey, hit)
+	span.SetAttributes(attribute.Bool("cache.hit", hit))
+	if err == cache.ErrMiss {
+		err = nil
+	}
+	tracing.End(span, err)
+	return hit
+}
+
+// setTagged stores v under cacheKey with the TTLs of tag and indexes it in the tag set
+func setTagged(cacheKey, tag string, v interface{}) {
+	ttl := tagTTLs(tag)
+	setCached(cacheKey, TagKey(tag), v, ttl.payload, ttl.tag)
+}
+
+// setCached stores v under cacheKey and, when tagKey is set, registers the key in that tag set
+func setCached(cacheKey, tagKey string, v interface{}, ttl, tagTTL time.Duration) {
+	payload, err := config.CacheCodec.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	if tagKey == "" {
+		_ = config.Cache.Set(config.Ctx, cacheKey, payload, ttl)
+		return
+	}
+	_ = config.Cache.SetTagged(config.Ctx, cacheKey, payload, ttl, tagKey, tagTTL)
+}
+
+// FlushTag deletes every payload indexed by tag and the tag set itself, returning the number of payloads removed
+func FlushTag(tag string) (int64, error) {
+	c := config.Cache
+	ctx := config.Ctx
+	tagKey := TagKey(tag)
+
+	keys, err := c.Members(ctx, tagKey)
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	for start := 0; start < len(keys); start += 500 {
+		end := start + 500
+		if end > len(keys) {
+			end = len(keys)
+		}
+		n, err := c.Del(ctx, keys[start:end]...)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	_, err = c.Del(ctx, tagKey)
+	return removed, err
+}
+
+// CacheTierStats returns the hits of the in-process L1 and of Redis since startup
+func CacheTierStats() cache.Stats {
+	if r, ok := config.Cache.(cache.StatsReporter); ok {
+		return r.Stats()
+	}
+	return cache.Stats{}
+}
+
+// CacheTagStats counts the members, live payloads and memory of every known tag
+func CacheTagStats() ([]TagStat, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	stats := make([]TagStat, 0, len(CacheTags))
+	for _, tag := range CacheTags {
+		keys, err := rdb.SMembers(ctx, TagKey(tag)).Result()
+		if err != nil {
+			return nil, err
+		}
+		stat := TagStat{Tag: tag, Keys: int64(len(keys))}
+
+		pipe := rdb.Pipeline()
+		usages := make([]*redis.IntCmd, len(keys))
+		for i, key := range keys {
+			usages[i] = pipe.MemoryUsage(ctx, key)
+		}
+		_, _ = pipe.Exec(ctx)
+		for _, usage := range usages {
+			if n, err := usage.Result(); err == nil {
+				stat.Live++
+				stat.Bytes += n
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
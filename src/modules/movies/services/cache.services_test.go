@@ -0,0 +1,29 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	"testing"
+	"time"
+)
+
+func TestTagSetsOutliveTheirPayloads(t *testing.T) {
+	previous := config.App
+	t.Cleanup(func() { config.App = previous })
+
+	for _, long := range []bool{false, true} {
+		config.App = config.Defaults()
+		if long {
+			config.App.Cache.TTL.Home = 3 * time.Hour
+			config.App.Jobs.TrendingInterval = 2 * time.Hour
+		}
+		for _, tag := range CacheTags {
+			ttl := tagTTLs(tag)
+			if ttl.payload <= 0 {
+				t.Errorf("%s: no payload TTL", tag)
+			}
+			if ttl.tag < ttl.payload {
+				t.Errorf("%s: tag set expires after %s, before its payloads (%s)", tag, ttl.tag, ttl.payload)
+			}
+		}
+	}
+}
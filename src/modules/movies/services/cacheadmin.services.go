@@ -0,0 +1,271 @@
+package movies
+
+import (
+	"ani4s/src/cache"
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	lib "ani4s/src/modules/movies/lib"
+	"ani4s/src/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var cacheLog = logger.Named("cache")
+
+// CachedEntry is one cached payload as seen by the admin API
+type CachedEntry struct {
+	Key     string          `json:"key"`
+	TTL     int64           `json:"ttl_seconds"` // -1 when the key has no expiry
+	Bytes   int64           `json:"bytes"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// RefreshResult reports what a refresh did
+type RefreshResult struct {
+	Refreshed int `json:"refreshed"`
+	Dropped   int `json:"dropped"`
+}
+
+// listPayload covers the list, search, category, country and newest payloads
+type listPayload struct {
+	Keyword    string           `json:"keyword,omitempty"`
+	Items      []lib.MovieItem  `json:"items"`
+	Pagination utils.Pagination `json:"pagination"`
+	Meta       utils.Meta       `json:"meta"`
+}
+
+func unknownTag(tag string) error {
+	return &utils.ServiceError{
+		StatusCode: http.StatusNotFound,
+		Code:       utils.ErrCodeNotFound,
+		Message:    fmt.Sprintf("unknown cache tag: %s", tag),
+	}
+}
+
+// IsCacheTag reports whether tag (short or full set name) is one of CacheTags
+func IsCacheTag(tag string) bool {
+	short := strings.TrimSuffix(tag, ":cached_keys")
+	for _, t := range CacheTags {
+		if t == short {
+			return true
+		}
+	}
+	return false
+}
+
+// TagMembers lists the keys indexed by tag
+func TagMembers(tag string) ([]string, error) {
+	if !IsCacheTag(tag) {
+		return nil, unknownTag(tag)
+	}
+	return config.Cache.Members(config.Ctx, TagKey(tag))
+}
+
+// InspectKey returns the payload stored under key with its TTL and size
+func InspectKey(key string) (*CachedEntry, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	payload, err := rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("cache key not found: %s", key),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &CachedEntry{Key: key, TTL: -1, Bytes: int64(len(payload))}
+	if ttl, err := rdb.TTL(ctx, key).Result(); err == nil && ttl > 0 {
+		entry.TTL = int64(ttl.Seconds())
+	}
+	if n, err := rdb.MemoryUsage(ctx, key).Result(); err == nil {
+		entry.Bytes = n
+	}
+	if entry.Payload, err = cache.ToJSON(payload); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// PurgeMovie deletes the details of slug and every tagged payload that lists it
+func PurgeMovie(slug string) (int64, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	needle := []byte(fmt.Sprintf(`"slug":%q`, slug))
+	victims := []string{fmt.Sprintf("movie_details:https://phimapi.com/phim/%s", slug)}
+
+	for _, tag := range CacheTags {
+		keys, err := rdb.SMembers(ctx, TagKey(tag)).Result()
+		if err != nil {
+			return 0, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		pipe := rdb.Pipeline()
+		payloads := make([]*redis.StringCmd, len(keys))
+		for i, key := range keys {
+			payloads[i] = pipe.Get(ctx, key)
+		}
+		_, _ = pipe.Exec(ctx)
+
+		for i, cmd := range payloads {
+			if b, err := cmd.Bytes(); err == nil && payloadContains(b, needle) {
+				victims = append(victims, keys[i])
+			}
+		}
+	}
+	return deleteKeys(victims)
+}
+
+// PurgePrefix deletes every key starting with prefix
+func PurgePrefix(prefix string) (int64, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	pattern := globEscaper.Replace(prefix) + "*"
+	var removed int64
+	batch := make([]string, 0, 500)
+	err := cache.ScanKeys(ctx, rdb, pattern, func(key string) error {
+		batch = append(batch, key)
+		if len(batch) < cap(batch) {
+			return nil
+		}
+		n, err := deleteKeys(batch)
+		removed += n
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return removed, err
+	}
+	n, err := deleteKeys(batch)
+	return removed + n, err
+}
+
+// payloadContains searches needle in the JSON form of a payload, whatever its codec
+func payloadContains(payload, needle []byte) bool {
+	b, err := cache.ToJSON(payload)
+	return err == nil && bytes.Contains(b, needle)
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func deleteKeys(keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return config.Cache.Del(config.Ctx, keys...)
+}
+
+// RefreshTag re-fetches every payload of tag from phimapi and drops the entries that
+// expired or can no longer be fetched, pruning the tag set along the way. The home,
+// trending and similar lists are built locally: they are rebuilt instead.
+func RefreshTag(ctx context.Context, tag string) (*RefreshResult, error) {
+	keys, err := TagMembers(tag)
+	if err != nil {
+		return nil, err
+	}
+	tag = strings.TrimSuffix(tag, ":cached_keys")
+	tagKey := TagKey(tag)
+	result := &RefreshResult{}
+
+	// The home is rebuilt from its collections, not re-fetched
+	if tag == "home" {
+		invalidateHome()
+		if _, err := GetHome(ctx); err != nil {
+			return nil, err
+		}
+		result.Refreshed = 1
+		return result, nil
+	}
+
+	// Rankings are recomputed from the hit buckets, their cached lists flushed
+	if tag == "trending" {
+		if err := ComputeTrending(); err != nil {
+			return nil, err
+		}
+		result.Refreshed = 1
+		return result, nil
+	}
+
+	// Similar titles are re-indexed; Refreshed counts the movies indexed
+	if tag == "movie_similar" {
+		n, err := RebuildRecommendations()
+		if err != nil {
+			return nil, err
+		}
+		result.Refreshed = n
+		return result, nil
+	}
+
+	drop := func(cacheKey string) {
+		_, _ = config.Cache.Del(ctx, cacheKey)
+		_ = config.Cache.Untag(ctx, tagKey, cacheKey)
+		result.Dropped++
+	}
+
+	for _, cacheKey := range keys {
+		// Details are refreshed through the movie sync
+		if tag == "movie_details" {
+			var cached lib.MovieDetailResponse
+			if !getCached(ctx, cacheKey, &cached) || cached.Movie.Slug == "" {
+				drop(cacheKey)
+				continue
+			}
+			if err := RefreshMovie(ctx, cached.Movie.Slug); err != nil {
+				cacheLog.Warn(ctx, "dropping cached entry", "key", cacheKey, "error", err)
+				drop(cacheKey)
+				continue
+			}
+			result.Refreshed++
+			continue
+		}
+
+		var cached listPayload
+		if !getCached(ctx, cacheKey, &cached) || cached.Meta.RequestURL == "" {
+			drop(cacheKey)
+			continue
+		}
+		items, pagination, err := FetchListPage(ctx, cached.Meta.RequestURL)
+		if err != nil {
+			cacheLog.Warn(ctx, "dropping cached entry", "key", cacheKey, "error", err)
+			drop(cacheKey)
+			continue
+		}
+
+		cached.Items = items
+		cached.Pagination = pagination
+		cached.Meta.FromCache = false
+		cached.Meta.Timestamp = time.Now().Unix()
+		setTagged(cacheKey, tag, &cached)
+		result.Refreshed++
+	}
+	return result, nil
+}
+
+// RefreshMovie re-syncs slug from phimapi and drops its cached details
+func RefreshMovie(ctx context.Context, slug string) error {
+	data, err := FetchMovieDetails(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if _, err := SaveMovieDetails(ctx, data); err != nil {
+		return err
+	}
+	InvalidateMovieDetails(slug)
+	return nil
+}
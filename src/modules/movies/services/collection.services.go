@@ -0,0 +1,275 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The home is cached as a whole. Its TTL never crosses the next start/end of a
+// scheduled collection, so sections appear and disappear on time, and every
+// collection or override write flushes it. Overrides are re-applied on read too,
+// so a replica with a newer snapshot never serves a movie that was just hidden.
+const (
+	homeCacheKey = "home:sections"
+)
+
+var homeLog = logger.Named("home")
+
+// GetHome returns the active collections rendered as homepage sections
+func GetHome(ctx context.Context) (*lib.HomeResponse, error) {
+	// 1. Redis cache
+	var cached lib.HomeResponse
+	if getCached(ctx, homeCacheKey, &cached) && cached.Sections != nil {
+		cached.Meta.FromCache = true
+		return applyHomeOverrides(ctx, &cached), nil
+	}
+
+	// 2. Build once per replica even when many requests miss together
+	metrics.SingleflightCalls.WithLabelValues("home").Inc()
+	raw, err, _ := requestGroup.Do(homeCacheKey, func() (interface{}, error) {
+		metrics.SingleflightExecutions.WithLabelValues("home").Inc()
+		// Waiters share this result: the leader leaving must not cancel the build
+		ctx := context.WithoutCancel(ctx)
+
+		var all []movies.Collection
+		if err := config.DB.WithContext(ctx).Order("position, slug").Find(&all).Error; err != nil {
+			return nil, fmt.Errorf("failed to load collections: %w", err)
+		}
+
+		now := time.Now()
+		sections := make([]lib.HomeSection, 0, len(all))
+		for i := range all {
+			c := &all[i]
+			if !c.ActiveAt(now) {
+				continue
+			}
+			section, err := resolveCollection(ctx, c)
+			if err != nil {
+				homeLog.Warn(ctx, "skipping collection", "collection", c.Slug, "error", err)
+				continue
+			}
+			if len(section.Items) > 0 {
+				sections = append(sections, *section)
+			}
+		}
+
+		result := &lib.HomeResponse{
+			Sections: sections,
+			Meta:     utils.Meta{FromCache: false, Timestamp: now.Unix()},
+		}
+		setCached(homeCacheKey, TagKey("home"), result, homeCacheTTL(all, now), tagTTLs("home").tag)
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyHomeOverrides(ctx, raw.(*lib.HomeResponse)), nil
+}
+
+// homeCacheTTL caps the home TTL at the next schedule boundary of an enabled collection
+func homeCacheTTL(all []movies.Collection, now time.Time) time.Duration {
+	ttl := config.App.Cache.TTL.Home
+	for _, c := range all {
+		if !c.Enabled {
+			continue
+		}
+		for _, bound := range []*time.Time{c.StartsAt, c.EndsAt} {
+			if bound != nil && bound.After(now) && bound.Sub(now) < ttl {
+				ttl = bound.Sub(now)
+			}
+		}
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+func applyHomeOverrides(ctx context.Context, res *lib.HomeResponse) *lib.HomeResponse {
+	o := currentOverrides(ctx)
+	out := *res
+	out.Sections = make([]lib.HomeSection, 0, len(res.Sections))
+	for _, s := range res.Sections {
+		s.Items = o.items(s.Items)
+		if len(s.Items) > 0 {
+			out.Sections = append(out.Sections, s)
+		}
+	}
+	return &out
+}
+
+// resolveCollection loads the movies of a collection, whatever its schedule
+func resolveCollection(ctx context.Context, c *movies.Collection) (*lib.HomeSection, error) {
+	section := &lib.HomeSection{Slug: c.Slug, Title: c.Title, Kind: c.Kind}
+
+	switch c.Kind {
+	case movies.CollectionManual:
+		items, err := moviesBySlugs(ctx, c.Slugs)
+		if err != nil {
+			return nil, err
+		}
+		section.Items = items
+	case movies.CollectionQuery:
+		if c.Filter == nil {
+			return nil, fmt.Errorf("collection %s has no filter", c.Slug)
+		}
+		res, err := runCollectionFilter(ctx, *c.Filter, c.Limit)
+		if err != nil {
+			return nil, err
+		}
+		section.Items = res.Items
+	default:
+		return nil, fmt.Errorf("unknown collection kind %q", c.Kind)
+	}
+
+	if len(section.Items) > c.Limit {
+		section.Items = section.Items[:c.Limit]
+	}
+	return section, nil
+}
+
+// runCollectionFilter answers a saved query through the cached listing services
+func runCollectionFilter(ctx context.Context, f movies.CollectionFilter, limit int) (*lib.MovieListResponse, error) {
+	if f.TypeList != "" {
+		return GetMovieList(ctx, lib.MovieListRequest{
+			TypeList:  f.TypeList,
+			Page:      1,
+			SortField: f.SortField,
+			SortType:  f.SortType,
+			SortLang:  f.SortLang,
+			Category:  f.Category,
+			Country:   f.Country,
+			Year:      f.Year,
+			Limit:     limit,
+		})
+	}
+
+	req := lib.MoviesByCategoryRequest{
+		Category:  f.Category,
+		Country:   f.Country,
+		Page:      1,
+		SortField: f.SortField,
+		SortType:  f.SortType,
+		SortLang:  f.SortLang,
+		Year:      f.Year,
+		Limit:     limit,
+	}
+	if f.Category != "" {
+		return ListMoviesByCategory(ctx, req)
+	}
+	return ListMoviesByCountry(ctx, req)
+}
+
+// moviesBySlugs returns the movies of slugs in that order, fetching the ones not mirrored yet
+func moviesBySlugs(ctx context.Context, slugs []string) ([]lib.MovieItem, error) {
+	bySlug, err := storedMovies(ctx, slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]lib.MovieItem, 0, len(slugs))
+	for _, slug := range slugs {
+		m, ok := bySlug[slug]
+		if !ok {
+			res, err := GetDetailsMovie(ctx, slug)
+			if err != nil {
+				homeLog.Warn(ctx, "movie unavailable", "slug", slug, "error", err)
+				continue
+			}
+			m = res.Movie
+		}
+		items = append(items, movieItemFromModel(m))
+	}
+	return currentOverrides(ctx).items(items), nil
+}
+
+// invalidateHome drops the cached home so the next request rebuilds it
+func invalidateHome() {
+	if _, err := FlushTag("home"); err != nil {
+		homeLog.Error(config.Ctx, "failed to flush cached home", "error", err)
+	}
+}
+
+func collectionNotFound(slug string) error {
+	return &utils.ServiceError{
+		StatusCode: http.StatusNotFound,
+		Code:       utils.ErrCodeNotFound,
+		Message:    fmt.Sprintf("collection not found: %s", slug),
+	}
+}
+
+// ListCollections returns every collection in homepage order, scheduled or not
+func ListCollections() ([]movies.Collection, error) {
+	all := []movies.Collection{}
+	if err := config.DB.Order("position, slug").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// PreviewCollection resolves a collection as it would render now, ignoring its schedule
+func PreviewCollection(ctx context.Context, slug string) (*lib.HomeSection, error) {
+	var c movies.Collection
+	err := config.DB.WithContext(ctx).Where("slug = ?", slug).First(&c).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, collectionNotFound(slug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resolveCollection(ctx, &c)
+}
+
+// SaveCollection creates or replaces a collection
+func SaveCollection(c *movies.Collection) error {
+	c.UpdatedAt = time.Now()
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		UpdateAll: true,
+	}).Create(c).Error
+	invalidateHome()
+	return err
+}
+
+// DeleteCollection removes a collection
+func DeleteCollection(slug string) error {
+	res := config.DB.Where("slug = ?", slug).Delete(&movies.Collection{})
+	invalidateHome()
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return collectionNotFound(slug)
+	}
+	return nil
+}
+
+// ReorderCollections sets the positions of the given collections to their index in slugs
+func ReorderCollections(slugs []string) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, slug := range slugs {
+			res := tx.Model(&movies.Collection{}).Where("slug = ?", slug).
+				Updates(map[string]interface{}{"position": i, "updated_at": time.Now()})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return collectionNotFound(slug)
+			}
+		}
+		return nil
+	})
+	invalidateHome()
+	return err
+}
@@ -2,134 +2,118 @@ package movies
 
 import (
 	"ani4s/src/config"
+	lib "ani4s/src/modules/movies/lib"
 	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 )
 
-func ListAllCategories() (map[string]interface{}, error) {
-	db := config.DB
-	rdb := config.RDB
-	ctx := config.Ctx
+func ListAllCategories(ctx context.Context) (*lib.CategoryListResponse, error) {
+	db := config.DB.WithContext(ctx)
 
 	targetURL := "https://phimapi.com/the-loai"
 	cacheKey := fmt.Sprintf("categories:%s", targetURL)
 
 	// 1. Redis Cache
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil && len(cached) > 0 {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			if rawData, ok := result["data"].([]interface{}); ok {
-				for _, item := range rawData {
-					if cat, ok := item.(map[string]interface{}); ok {
-						if cat["slug"] == "mien-tay" {
-							cat["name"] = "Cao Bồi"
-						}
-					}
-				}
-			}
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached lib.CategoryListResponse
+	if getCached(ctx, cacheKey, &cached) && len(cached.Items) > 0 {
+		cached.Meta.FromCache = true
+		return applyCategoryOverrides(ctx, &cached), nil
 	}
 
-	// 2. Fetch from API
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	// 2. Fetch from API, falling back to the local table
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+		if local, dbErr := ListCategoriesFromDB(ctx); dbErr == nil && len(local.Items) > 0 {
+			return applyCategoryOverrides(ctx, local), nil
+		}
+		return nil, upstreamError("categories", err)
 	}
 
 	// 3. Parse JSON array
 	var rawCategories []movies.Category
 	if err := json.Unmarshal(responseBody, &rawCategories); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
-	}
-
-	// ✅ 3.5. Override "Miền Tây" → "Cao Bồi"
-	for i := range rawCategories {
-		if rawCategories[i].Slug == "mien-tay" {
-			rawCategories[i].Name = "Cao Bồi"
+		return nil, &utils.ServiceError{
+			StatusCode: http.StatusBadGateway,
+			Code:       utils.ErrCodeUpstream,
+			Message:    "failed to parse API response",
 		}
 	}
 
-	// 4. Sync categories to DB
-	for _, cat := range rawCategories {
-		_ = db.FirstOrCreate(&movies.Category{}, movies.Category{ID: cat.ID}).Error
+	// 4. Sync categories to DB, keyed by slug
+	if _, err := categoryTaxonomy.upsert(db, categoryRows(rawCategories)); err != nil {
+		moviesLog.Error(ctx, "failed to sync categories", "error", err)
 	}
 
-	// 5. Prepare normalized response
-	result := map[string]interface{}{
-		"success":     true,
-		"data":        rawCategories,
-		"from_cache":  false,
-		"request_url": targetURL,
-		"timestamp":   time.Now().Unix(),
+	// 5. Prepare typed response
+	result := &lib.CategoryListResponse{
+		Items: rawCategories,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
 
 	// 6. Cache response
-	if jsonBytes, err := json.Marshal(result); err == nil {
-		_ = rdb.Set(ctx, cacheKey, jsonBytes, 23*time.Hour).Err()
-	}
+	setCached(cacheKey, "", result, config.App.Cache.TTL.Taxonomy, 0)
 
-	return result, nil
+	return applyCategoryOverrides(ctx, result), nil
 }
 
-func ListAllCountry() (map[string]interface{}, error) {
-	db := config.DB
-	rdb := config.RDB
-	ctx := config.Ctx
+func ListAllCountry(ctx context.Context) (*lib.CountryListResponse, error) {
+	db := config.DB.WithContext(ctx)
 
 	targetURL := "https://phimapi.com/quoc-gia"
 	cacheKey := fmt.Sprintf("countries:%s", targetURL)
 
 	// 1. Redis Cache
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil && len(cached) > 0 {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached lib.CountryListResponse
+	if getCached(ctx, cacheKey, &cached) && len(cached.Items) > 0 {
+		cached.Meta.FromCache = true
+		return &cached, nil
 	}
 
-	// 2. Fetch from API
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	// 2. Fetch from API, falling back to the local table
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch countries: %w", err)
+		if local, dbErr := ListCountriesFromDB(ctx); dbErr == nil && len(local.Items) > 0 {
+			return local, nil
+		}
+		return nil, upstreamError("countries", err)
 	}
 
 	// 3. Parse JSON array
 	var rawCountry []movies.Country
 	if err := json.Unmarshal(responseBody, &rawCountry); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
+		return nil, &utils.ServiceError{
+			StatusCode: http.StatusBadGateway,
+			Code:       utils.ErrCodeUpstream,
+			Message:    "failed to parse API response",
+		}
 	}
 
-	// 4. Sync categories to DB
-	for _, cat := range rawCountry {
-		_ = db.FirstOrCreate(&movies.Country{}, movies.Country{Name: cat.Name}).Error
+	// 4. Sync countries to DB, keyed by slug
+	if _, err := countryTaxonomy.upsert(db, countryRows(rawCountry)); err != nil {
+		moviesLog.Error(ctx, "failed to sync countries", "error", err)
 	}
 
-	// 5. Prepare normalized response
-	result := map[string]interface{}{
-		"success":     true,
-		"data":        rawCountry,
-		"from_cache":  false,
-		"request_url": targetURL,
-		"timestamp":   time.Now().Unix(),
+	// 5. Prepare typed response
+	result := &lib.CountryListResponse{
+		Items: rawCountry,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
 
 	// 6. Cache response
-	if jsonBytes, err := json.Marshal(result); err == nil {
-		_ = rdb.Set(ctx, cacheKey, jsonBytes, 23*time.Hour).Err()
-	}
+	setCached(cacheKey, "", result, config.App.Cache.TTL.Taxonomy, 0)
 
 	return result, nil
 }
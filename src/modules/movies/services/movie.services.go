@@ -2,23 +2,44 @@ package movies
 
 import (
 	"ani4s/src/config"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
 	lib "ani4s/src/modules/movies/lib"
 	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/tracing"
 	"ani4s/src/utils"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"gorm.io/gorm/clause"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// MakeAnonymousRequest makes a GET request while masking server information
-func MakeAnonymousRequest(url string) ([]byte, error) {
+var moviesLog = logger.Named("movies")
+
+// MakeAnonymousRequest makes a GET request while masking server information.
+// ctx only parents the span: no trace header is sent upstream, and the request is not
+// cancelled with ctx because singleflight waiters may share its result.
+func MakeAnonymousRequest(ctx context.Context, url string) (body []byte, err error) {
+	endpoint := upstreamEndpoint(url)
+	start := time.Now()
+	_, span := tracing.StartClient(ctx, "GET "+endpoint,
+		attribute.String("http.request.method", http.MethodGet),
+		attribute.String("url.full", url),
+		attribute.String("upstream.endpoint", endpoint),
+	)
+	defer func() {
+		metrics.UpstreamDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}()
+
 	// Create a custom HTTP client with modifications to hide server info
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -54,9 +75,14 @@ func MakeAnonymousRequest(url string) ([]byte, error) {
 	// Make the request
 	resp, err := client.Do(req)
 	if err != nil {
+		metrics.UpstreamErrors.WithLabelValues(endpoint, "request").Inc()
 		return nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		metrics.UpstreamErrors.WithLabelValues(endpoint, "status").Inc()
+	}
 
 	// Handle compressed responses
 	var reader io.Reader = resp.Body
@@ -65,6 +91,7 @@ func MakeAnonymousRequest(url string) ([]byte, error) {
 	if strings.Contains(resp.Header.Get("Content-Encoding"), "gzip") {
 		gzipReader, err := gzip.NewReader(resp.Body)
 		if err != nil {
+			metrics.UpstreamErrors.WithLabelValues(endpoint, "read").Inc()
 			return nil, err
 		}
 		defer gzipReader.Close()
@@ -72,18 +99,16 @@ func MakeAnonymousRequest(url string) ([]byte, error) {
 	}
 
 	// Read the response body
-	body, err := io.ReadAll(reader)
+	body, err = io.ReadAll(reader)
 	if err != nil {
+		metrics.UpstreamErrors.WithLabelValues(endpoint, "read").Inc()
 		return nil, err
 	}
 
 	return body, nil
 }
 
-func GetListNewestMovies(page, v int) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
-
+func GetListNewestMovies(ctx context.Context, page, v int) (*lib.MovieListResponse, error) {
 	// Xây dựng URL
 	var targetURL string
 	if v == 1 {
@@ -95,161 +120,128 @@ func GetListNewestMovies(page, v int) (map[string]interface{}, error) {
 	cacheKey := fmt.Sprintf("movie_newest:%s", targetURL)
 
 	// 1. Thử lấy từ Redis cache
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil && len(cached) > 0 {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached lib.MovieListResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applyListOverrides(ctx, &cached), nil
 	}
 
 	// 2. Gọi API gốc
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch newest movie list: %w", err)
+		return nil, upstreamError("newest movie list", err)
 	}
 
-	// 3. Parse JSON dạng bất kỳ
-	var raw map[string]interface{}
-	if err := json.Unmarshal(responseBody, &raw); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
+	// 3. Parse về dạng typed
+	items, pagination, err := decodeUpstreamList(ctx, responseBody)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Normalize theo chuẩn `data.item`
-	normalized, ok := utils.IsValidApiResponse(raw)
-	if !ok {
-		return map[string]interface{}{
-			"success": false,
-			"error":   "Unexpected response structure",
-		}, nil
+	// 4. Metadata + cache
+	result := &lib.MovieListResponse{
+		Items:      items,
+		Pagination: pagination,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
+	setTagged(cacheKey, "movie_newest", result)
 
-	// 5. Metadata + cache
-	normalized["from_cache"] = false
-	normalized["request_url"] = targetURL
-	normalized["timestamp"] = time.Now().Unix()
-
-	jsonStr, _ := json.Marshal(normalized)
-	ttl := 16 * time.Hour
-	tagKey := "movie_newest:cached_keys"
-
-	pipe := rdb.Pipeline()
-	pipe.Set(ctx, cacheKey, jsonStr, ttl)
-	pipe.SAdd(ctx, tagKey, cacheKey)
-	pipe.Expire(ctx, tagKey, ttl)
-	_, _ = pipe.Exec(ctx)
-
-	// 6. Trả về map
-	var returnMap map[string]interface{}
-	_ = json.Unmarshal(jsonStr, &returnMap)
-
-	return returnMap, nil
+	return applyListOverrides(ctx, result), nil
 }
 
-func GetDetailsMovie(slug string) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
-	db := config.DB
+func GetDetailsMovie(ctx context.Context, slug string) (*lib.MovieDetailResponse, error) {
+	// Taken down by an admin override
+	if err := movieHiddenError(ctx, slug); err != nil {
+		return nil, err
+	}
 
 	targetURL := fmt.Sprintf("https://phimapi.com/phim/%s", slug)
 	cacheKey := fmt.Sprintf("movie_details:%s", targetURL)
 
 	// 1. Check Redis cache
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached lib.MovieDetailResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Movie.ID != "" {
+		cached.Meta.FromCache = true
+		return applyDetailOverrides(ctx, &cached), nil
 	}
 
 	// 2. Check local DB
-	if res, err := GetMovieDetailsFromDB(slug); err == nil {
-		res["from_cache"] = false
-		return res, nil
+	if res, err := GetMovieDetailsFromDB(ctx, slug); err == nil {
+		res.Meta.FromCache = false
+		return applyDetailOverrides(ctx, res), nil
 	} else {
-		fmt.Printf("[MovieDetails] Fallback to API for slug %s due to DB error: %v\n", slug, err)
+		moviesLog.Debug(ctx, "details not stored, falling back to API", "slug", slug, "error", err)
 	}
 
 	// 3. Fetch from external API
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	data, err := FetchMovieDetails(ctx, slug)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch movie details: %w", err)
+		return nil, err
 	}
 
-	// 4. Parse API response
-	var data movies.MovieDetails
-	if err := json.Unmarshal(responseBody, &data); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Invalid API response",
-			"raw_data": string(responseBody),
-		}, nil
-	}
-
-	// 5. Save to DB with transaction
-	tx := db.Begin()
-
-	if err := tx.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}},
-		DoNothing: true,
-	}).Create(&data.Movie).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to upsert movie: %w", err)
-	}
-
-	for _, group := range data.Episodes {
-		for _, ep := range group.ServerData {
-			ep.MovieID = data.Movie.ID
-			ep.Movie = movies.Movie{}
-			ep.ServerName = group.ServerName
-
-			err := tx.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "slug"}},
-				DoNothing: true,
-			}).Create(&ep).Error
-
-			if err != nil && !strings.Contains(err.Error(), "duplicate key") {
-				tx.Rollback()
-				return nil, fmt.Errorf("failed to upsert episode: %w", err)
-			}
-		}
+	// 4. Save to DB
+	if _, err := SaveMovieDetails(ctx, data); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	// 5. Build response
+	result := &lib.MovieDetailResponse{
+		MovieDetail: lib.MovieDetail{
+			Movie:    data.Movie,
+			Episodes: data.Episodes,
+		},
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
 
-	// 6. Build response
-	result := map[string]interface{}{
-		"data": map[string]interface{}{
-			"movie":    data.Movie,
-			"episodes": data.Episodes,
-		},
-		"from_cache":  false,
-		"request_url": targetURL,
-		"timestamp":   time.Now().Unix(),
+	// 6. Save to Redis cache
+	setTagged(cacheKey, "movie_details", result)
+
+	return applyDetailOverrides(ctx, result), nil
+}
+
+// FetchMovieDetails loads the details of slug straight from phimapi, bypassing cache and DB
+func FetchMovieDetails(ctx context.Context, slug string) (*movies.MovieDetails, error) {
+	targetURL := fmt.Sprintf("https://phimapi.com/phim/%s", slug)
+
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
+	if err != nil {
+		return nil, upstreamError("movie details", err)
 	}
 
-	// 7. Save to Redis cache
-	if jsonStr, err := json.Marshal(result); err == nil {
-		ttl := 16 * time.Hour
-		pipe := rdb.Pipeline()
-		pipe.Set(ctx, cacheKey, jsonStr, ttl)
-		_, _ = pipe.Exec(ctx)
+	var data movies.MovieDetails
+	if err := json.Unmarshal(responseBody, &data); err != nil {
+		return nil, &utils.ServiceError{
+			StatusCode: http.StatusBadGateway,
+			Code:       utils.ErrCodeUpstream,
+			Message:    "invalid API response",
+		}
+	}
+	if data.Movie.ID == "" {
+		return nil, &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("movie not found: %s", slug),
+		}
 	}
+	return &data, nil
+}
 
-	return result, nil
+// InvalidateMovieDetails drops the cached details of slug so the next read comes from the DB
+func InvalidateMovieDetails(slug string) {
+	cacheKey := fmt.Sprintf("movie_details:https://phimapi.com/phim/%s", slug)
+	_, _ = config.Cache.Del(config.Ctx, cacheKey)
 }
 
-func GetMovieDetailsFromDB(slug string) (map[string]interface{}, error) {
-	db := config.DB
-	rdb := config.RDB
-	ctx := config.Ctx
+func GetMovieDetailsFromDB(ctx context.Context, slug string) (*lib.MovieDetailResponse, error) {
+	db := config.DB.WithContext(ctx)
 
 	targetURL := fmt.Sprintf("https://phimapi.com/phim/%s", slug)
 	cacheKey := fmt.Sprintf("movie_details:%s", targetURL)
@@ -268,7 +260,7 @@ func GetMovieDetailsFromDB(slug string) (map[string]interface{}, error) {
 
 	// ❗️Nếu không có episodes => xoá Redis và trả lỗi để fallback sang API
 	if len(allEpisodes) == 0 {
-		_ = rdb.Del(ctx, cacheKey).Err()
+		_, _ = config.Cache.Del(ctx, cacheKey)
 		return nil, fmt.Errorf("no episodes found for movie slug: %s", slug)
 	}
 
@@ -288,90 +280,70 @@ func GetMovieDetailsFromDB(slug string) (map[string]interface{}, error) {
 	}
 
 	// 4. Format response
-	result := map[string]interface{}{
-		"data": map[string]interface{}{
-			"movie":    movie,
-			"episodes": episodeGroups,
+	result := &lib.MovieDetailResponse{
+		MovieDetail: lib.MovieDetail{
+			Movie:    movie,
+			Episodes: episodeGroups,
+		},
+		Meta: utils.Meta{
+			FromCache:  true,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
 		},
-		"from_cache":  true,
-		"request_url": targetURL,
-		"timestamp":   time.Now().Unix(),
 	}
 
 	// 5. Lưu vào Redis
-	if jsonStr, err := json.Marshal(result); err == nil {
-		ttl := 16 * time.Hour
-		tagKey := "movie_details:cached_keys"
-
-		pipe := rdb.Pipeline()
-		pipe.Set(ctx, cacheKey, jsonStr, ttl)
-		pipe.SAdd(ctx, tagKey, cacheKey)
-		pipe.Expire(ctx, tagKey, ttl)
-		_, _ = pipe.Exec(ctx)
-	}
+	setTagged(cacheKey, "movie_details", result)
 
 	return result, nil
 }
 
-func ListMoviesByCategory(req lib.MoviesByCategoryRequest) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
+func ListMoviesByCategory(ctx context.Context, req lib.MoviesByCategoryRequest) (*lib.MovieListResponse, error) {
+	if err := categoryHiddenError(ctx, req.Category); err != nil {
+		return nil, err
+	}
 
 	targetURL := buildCategoryURL(req)
 	cacheKey := buildCategoryCacheKey(req)
 
 	// 1. Redis cache
-	if cached, err := rdb.Get(ctx, cacheKey).Result(); err == nil {
-		var cachedData map[string]interface{}
-		if err := json.Unmarshal([]byte(cached), &cachedData); err == nil {
-			cachedData["from_cache"] = true
-			return cachedData, nil
-		}
+	var cached lib.MovieListResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applyListOverrides(ctx, &cached), nil
 	}
 
 	// 2. Call phimapi
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
+	if err != nil {
+		// phimapi down: serve what the crawler mirrored
+		if local, dbErr := ListMoviesFromDB(ctx, req); dbErr == nil && len(local.Items) > 0 {
+			return applyListOverrides(ctx, local), nil
+		}
+		return nil, upstreamError("category movies", err)
+	}
+
+	// 3. Parse về dạng typed
+	items, pagination, err := decodeUpstreamList(ctx, responseBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch category movies: %w", err)
-	}
-
-	// 3. Parse raw JSON
-	var raw map[string]interface{}
-	if err := json.Unmarshal(responseBody, &raw); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
-	}
-
-	// 4. Chuẩn hoá về dạng data.items
-	normalized, ok := utils.IsValidApiResponse(raw)
-	if !ok {
-		return map[string]interface{}{
-			"success": false,
-			"error":   "Unexpected response structure",
-		}, nil
-	}
-	// 5. Add metadata
-	normalized["from_cache"] = false
-	normalized["request_url"] = targetURL
-	normalized["timestamp"] = time.Now().Unix()
-
-	// 6. Cache
-	jsonStr, _ := json.Marshal(normalized)
-	_ = rdb.Set(ctx, cacheKey, jsonStr, 16*time.Hour).Err()
-
-	tagKey := "movie_category:cached_keys"
-	ttl := 16 * time.Hour
-
-	pipe := rdb.Pipeline()
-	pipe.Set(ctx, cacheKey, jsonStr, ttl)
-	pipe.SAdd(ctx, tagKey, cacheKey)
-	pipe.Expire(ctx, tagKey, ttl)
-	_, _ = pipe.Exec(ctx)
-
-	return normalized, nil
+		return nil, err
+	}
+
+	// 4. Add metadata
+	result := &lib.MovieListResponse{
+		Items:      items,
+		Pagination: pagination,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
+	}
+
+	// 5. Cache
+	setTagged(cacheKey, "movie_category", result)
+
+	return applyListOverrides(ctx, result), nil
 }
 
 // buildCategoryURL constructs the phimapi.com URL for category browsing
@@ -403,65 +375,48 @@ func buildCategoryCacheKey(req lib.MoviesByCategoryRequest) string {
 }
 
 // ---------COUNTRY SIDE SERVICES---------------//
-func ListMoviesByCountry(req lib.MoviesByCategoryRequest) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
-
+func ListMoviesByCountry(ctx context.Context, req lib.MoviesByCategoryRequest) (*lib.MovieListResponse, error) {
 	targetURL := buildCountryURL(req)
 	cacheKey := buildCountryCacheKey(req)
 
 	// 1. Redis cache
-	if cached, err := rdb.Get(ctx, cacheKey).Result(); err == nil {
-		var cachedData map[string]interface{}
-		if err := json.Unmarshal([]byte(cached), &cachedData); err == nil {
-			cachedData["from_cache"] = true
-			return cachedData, nil
-		}
+	var cached lib.MovieListResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applyListOverrides(ctx, &cached), nil
 	}
 
 	// 2. Call remote API
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch country movies: %w", err)
+		// phimapi down: serve what the crawler mirrored
+		if local, dbErr := ListMoviesFromDB(ctx, req); dbErr == nil && len(local.Items) > 0 {
+			return applyListOverrides(ctx, local), nil
+		}
+		return nil, upstreamError("country movies", err)
 	}
 
-	// 3. Parse raw response
-	var raw map[string]interface{}
-	if err := json.Unmarshal(responseBody, &raw); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
+	// 3. Parse về dạng typed
+	items, pagination, err := decodeUpstreamList(ctx, responseBody)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Normalize response to `data.items`
-	normalized, ok := utils.IsValidApiResponse(raw)
-	if !ok {
-		return map[string]interface{}{
-			"success": false,
-			"error":   "Unexpected response structure",
-		}, nil
+	// 4. Add metadata
+	result := &lib.MovieListResponse{
+		Items:      items,
+		Pagination: pagination,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
 
-	// 5. Add metadata
-	normalized["from_cache"] = false
-	normalized["timestamp"] = time.Now().Unix()
-	normalized["request_url"] = targetURL
-
-	// 6. Cache it
-	if jsonStr, err := json.Marshal(normalized); err == nil {
-		tagKey := "movie_country:cached_keys"
-		ttl := 16 * time.Hour
-
-		pipe := rdb.Pipeline()
-		pipe.Set(ctx, cacheKey, jsonStr, ttl)
-		pipe.SAdd(ctx, tagKey, cacheKey)
-		pipe.Expire(ctx, tagKey, ttl)
-		_, _ = pipe.Exec(ctx)
-	}
+	// 5. Cache it
+	setTagged(cacheKey, "movie_country", result)
 
-	return normalized, nil
+	return applyListOverrides(ctx, result), nil
 }
 
 func buildCountryURL(req lib.MoviesByCategoryRequest) string {
@@ -0,0 +1,287 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm/clause"
+)
+
+var overridesLog = logger.Named("overrides")
+
+// Overrides are applied when a payload is served, never baked into the cache,
+// so a change takes effect without purging anything. Each replica keeps a snapshot
+// that is reloaded every overridesTTL and dropped right away when it writes one.
+const overridesTTL = 30 * time.Second
+
+type overrideSet struct {
+	categories map[string]movies.CategoryOverride
+	movies     map[string]movies.MovieOverride
+}
+
+var (
+	overridesMu       sync.RWMutex
+	overridesSnapshot *overrideSet
+	overridesLoadedAt time.Time
+	// overridesGen is bumped by every reset; a reload started before it is not kept
+	overridesGen   uint64
+	overridesGroup singleflight.Group
+)
+
+var emptyOverrides = &overrideSet{
+	categories: map[string]movies.CategoryOverride{},
+	movies:     map[string]movies.MovieOverride{},
+}
+
+// currentOverrides returns the overrides snapshot, reloading it when stale. Requests
+// arriving together share one reload.
+func currentOverrides(ctx context.Context) *overrideSet {
+	overridesMu.RLock()
+	snap, loadedAt, gen := overridesSnapshot, overridesLoadedAt, overridesGen
+	overridesMu.RUnlock()
+	if snap != nil && time.Since(loadedAt) < overridesTTL {
+		return snap
+	}
+	if config.DB == nil {
+		return emptyOverrides
+	}
+
+	// Requests after a reset do not join a reload that may have read the old rows
+	raw, _, _ := overridesGroup.Do("overrides:"+strconv.FormatUint(gen, 10), func() (interface{}, error) {
+		// Waiters share this reload: the leader leaving must not cancel it
+		return loadOverrides(context.WithoutCancel(ctx), snap, gen), nil
+	})
+	return raw.(*overrideSet)
+}
+
+// loadOverrides reads every override into a new snapshot, or returns the last one when
+// the DB cannot be read. The snapshot is only kept while the generation is still gen.
+func loadOverrides(ctx context.Context, snap *overrideSet, gen uint64) *overrideSet {
+	db := config.DB.WithContext(ctx)
+	var cats []movies.CategoryOverride
+	var movs []movies.MovieOverride
+	if err := db.Find(&cats).Error; err != nil {
+		overridesLog.Error(ctx, "failed to load category overrides", "error", err)
+		return fallbackOverrides(snap)
+	}
+	if err := db.Find(&movs).Error; err != nil {
+		overridesLog.Error(ctx, "failed to load movie overrides", "error", err)
+		return fallbackOverrides(snap)
+	}
+
+	next := &overrideSet{
+		categories: make(map[string]movies.CategoryOverride, len(cats)),
+		movies:     make(map[string]movies.MovieOverride, len(movs)),
+	}
+	for _, o := range cats {
+		next.categories[o.Slug] = o
+	}
+	for _, o := range movs {
+		next.movies[o.Slug] = o
+	}
+
+	overridesMu.Lock()
+	if overridesGen == gen {
+		overridesSnapshot, overridesLoadedAt = next, time.Now()
+	}
+	overridesMu.Unlock()
+	return next
+}
+
+// fallbackOverrides keeps serving the last snapshot when the DB is unreachable
+func fallbackOverrides(snap *overrideSet) *overrideSet {
+	if snap != nil {
+		return snap
+	}
+	return emptyOverrides
+}
+
+// resetOverrides drops the snapshot after a write, along with any reload in flight
+func resetOverrides() {
+	overridesMu.Lock()
+	overridesSnapshot = nil
+	overridesGen++
+	overridesMu.Unlock()
+}
+
+// categoryList drops hidden categories and applies renames, returning a new slice
+func (o *overrideSet) categoryList(in []movies.Category) []movies.Category {
+	if in == nil {
+		return nil
+	}
+	out := make([]movies.Category, 0, len(in))
+	for _, c := range in {
+		ov, ok := o.categories[c.Slug]
+		if ok && ov.Hidden {
+			continue
+		}
+		if ok && ov.Name != "" {
+			c.Name = ov.Name
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// items drops hidden movies and applies overrides, returning a new slice
+func (o *overrideSet) items(in []lib.MovieItem) []lib.MovieItem {
+	if in == nil {
+		return nil
+	}
+	out := make([]lib.MovieItem, 0, len(in))
+	for _, it := range in {
+		ov, ok := o.movies[it.Slug]
+		if ok && ov.Hidden {
+			continue
+		}
+		if ok {
+			it.Name = pick(ov.Name, it.Name)
+			it.PosterURL = pick(ov.PosterURL, it.PosterURL)
+			it.ThumbURL = pick(ov.ThumbURL, it.ThumbURL)
+		}
+		it.Categories = o.categoryList(it.Categories)
+		out = append(out, it)
+	}
+	return out
+}
+
+func pick(override, value string) string {
+	if override != "" {
+		return override
+	}
+	return value
+}
+
+func applyListOverrides(ctx context.Context, res *lib.MovieListResponse) *lib.MovieListResponse {
+	out := *res
+	out.Items = currentOverrides(ctx).items(res.Items)
+	return &out
+}
+
+func applySearchOverrides(ctx context.Context, res *lib.MovieSearchResponse) *lib.MovieSearchResponse {
+	out := *res
+	out.Items = currentOverrides(ctx).items(res.Items)
+	return &out
+}
+
+func applyCategoryOverrides(ctx context.Context, res *lib.CategoryListResponse) *lib.CategoryListResponse {
+	out := *res
+	out.Items = currentOverrides(ctx).categoryList(res.Items)
+	return &out
+}
+
+func applyDetailOverrides(ctx context.Context, res *lib.MovieDetailResponse) *lib.MovieDetailResponse {
+	o := currentOverrides(ctx)
+	out := *res
+	if ov, ok := o.movies[out.Movie.Slug]; ok {
+		out.Movie.Name = pick(ov.Name, out.Movie.Name)
+		out.Movie.PosterURL = pick(ov.PosterURL, out.Movie.PosterURL)
+		out.Movie.ThumbURL = pick(ov.ThumbURL, out.Movie.ThumbURL)
+		out.Movie.Content = pick(ov.Content, out.Movie.Content)
+	}
+	out.Movie.Categories = o.categoryList(out.Movie.Categories)
+	return &out
+}
+
+// ApplyCategoryOverrides renames and filters categories loaded outside the services (GraphQL loaders)
+func ApplyCategoryOverrides(ctx context.Context, in []movies.Category) []movies.Category {
+	return currentOverrides(ctx).categoryList(in)
+}
+
+// movieHiddenError is returned for a movie taken down by an override
+func movieHiddenError(ctx context.Context, slug string) error {
+	if ov, ok := currentOverrides(ctx).movies[slug]; ok && ov.Hidden {
+		return &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("movie not found: %s", slug),
+		}
+	}
+	return nil
+}
+
+// categoryHiddenError is returned for a listing of a hidden category
+func categoryHiddenError(ctx context.Context, slug string) error {
+	if ov, ok := currentOverrides(ctx).categories[slug]; ok && ov.Hidden {
+		return &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("category not found: %s", slug),
+		}
+	}
+	return nil
+}
+
+// ListOverrides returns every category and movie override
+func ListOverrides() ([]movies.CategoryOverride, []movies.MovieOverride, error) {
+	cats := []movies.CategoryOverride{}
+	movs := []movies.MovieOverride{}
+	if err := config.DB.Order("slug").Find(&cats).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := config.DB.Order("slug").Find(&movs).Error; err != nil {
+		return nil, nil, err
+	}
+	return cats, movs, nil
+}
+
+// SaveCategoryOverride creates or replaces the override of a category
+func SaveCategoryOverride(o *movies.CategoryOverride) error {
+	o.UpdatedAt = time.Now()
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		UpdateAll: true,
+	}).Create(o).Error
+	resetOverrides()
+	invalidateHome()
+	return err
+}
+
+// SaveMovieOverride creates or replaces the override of a movie
+func SaveMovieOverride(o *movies.MovieOverride) error {
+	o.UpdatedAt = time.Now()
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		UpdateAll: true,
+	}).Create(o).Error
+	resetOverrides()
+	invalidateHome()
+	return err
+}
+
+// DeleteCategoryOverride removes the override of a category
+func DeleteCategoryOverride(slug string) error {
+	return deleteOverride(&movies.CategoryOverride{}, slug)
+}
+
+// DeleteMovieOverride removes the override of a movie
+func DeleteMovieOverride(slug string) error {
+	return deleteOverride(&movies.MovieOverride{}, slug)
+}
+
+func deleteOverride(model interface{}, slug string) error {
+	res := config.DB.Where("slug = ?", slug).Delete(model)
+	resetOverrides()
+	invalidateHome()
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("no override for %s", slug),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	movies "ani4s/src/modules/movies/models"
+	"context"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// A reset while a reload is reading the old rows must not let that reload's
+// snapshot be served afterwards
+func TestResetOverridesDuringReload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is its own database
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&movies.CategoryOverride{}, &movies.MovieOverride{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&movies.MovieOverride{Slug: "a", Name: "old"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the first reload once it has read the movie overrides
+	read, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	err = db.Callback().Query().After("gorm:query").Register("test:hold", func(tx *gorm.DB) {
+		if tx.Statement.Table == "movie_overrides" {
+			once.Do(func() {
+				close(read)
+				<-release
+			})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := config.DB
+	config.DB = db
+	resetOverrides()
+	t.Cleanup(func() {
+		config.DB = previous
+		resetOverrides()
+	})
+
+	ctx := context.Background()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		currentOverrides(ctx)
+	}()
+	<-read
+	if err := db.Model(&movies.MovieOverride{}).Where("slug = ?", "a").Update("name", "new").Error; err != nil {
+		t.Fatal(err)
+	}
+	resetOverrides()
+	close(release)
+	<-done
+
+	if got := currentOverrides(ctx).movies["a"].Name; got != "new" {
+		t.Errorf("override name after the reset = %q, want %q", got, "new")
+	}
+}
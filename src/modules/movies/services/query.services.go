@@ -1,10 +1,10 @@
 package movies
 
 import (
-	"ani4s/src/config"
+	"ani4s/src/metrics"
 	movies "ani4s/src/modules/movies/lib"
 	"ani4s/src/utils"
-	"encoding/json"
+	"context"
 	"fmt"
 	"golang.org/x/sync/singleflight"
 	"net/url"
@@ -13,134 +13,108 @@ import (
 
 var requestGroup singleflight.Group
 
-func GetMovieList(req movies.MovieListRequest) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
+func GetMovieList(ctx context.Context, req movies.MovieListRequest) (*movies.MovieListResponse, error) {
+	if err := categoryHiddenError(ctx, req.Category); err != nil {
+		return nil, err
+	}
 
 	cacheKey := buildCacheKey(req)
 
 	// 1. Try Redis cache
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil && len(cached) > 0 {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached movies.MovieListResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applyListOverrides(ctx, &cached), nil
 	}
 
 	// 2. Use singleflight to prevent duplicate API calls
+	metrics.SingleflightCalls.WithLabelValues("movie_list").Inc()
 	rawResult, err, _ := requestGroup.Do(cacheKey, func() (interface{}, error) {
+		metrics.SingleflightExecutions.WithLabelValues("movie_list").Inc()
+		// Waiters share this result: the leader leaving must not cancel the fetch
+		ctx := context.WithoutCancel(ctx)
+
 		// 2a. Fetch from remote API
 		targetURL := buildListURL(req)
-		body, err := MakeAnonymousRequest(targetURL)
+		body, err := MakeAnonymousRequest(ctx, targetURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch from upstream API: %w", err)
+			return nil, upstreamError("movie list", err)
 		}
 
-		var apiResponse map[string]interface{}
-		if err := json.Unmarshal(body, &apiResponse); err != nil {
-			return nil, fmt.Errorf("invalid JSON from upstream: %w", err)
-		}
-
-		normalized, ok := utils.IsValidApiResponse(apiResponse)
-		if !ok {
-			return map[string]interface{}{
-				"success": false,
-				"error":   "Unexpected response structure, skip caching",
-			}, nil
+		// 2b. Parse về dạng typed
+		items, pagination, err := decodeUpstreamList(ctx, body)
+		if err != nil {
+			return nil, err
 		}
 
 		// Add metadata
-		normalized["from_cache"] = false
-		normalized["request_url"] = targetURL
-		normalized["timestamp"] = time.Now().Unix()
-
-		// 2c. Encode to JSON once
-		jsonBytes, _ := json.Marshal(apiResponse)
-
-		// 2d. Save to Redis with pipeline
-		ttl := 16 * time.Hour
-		tagKey := "movie_list:cached_keys"
+		result := &movies.MovieListResponse{
+			Items:      items,
+			Pagination: pagination,
+			Meta: utils.Meta{
+				FromCache:  false,
+				RequestURL: targetURL,
+				Timestamp:  time.Now().Unix(),
+			},
+		}
 
-		pipe := rdb.Pipeline()
-		pipe.Set(ctx, cacheKey, jsonBytes, ttl)
-		pipe.SAdd(ctx, tagKey, cacheKey)
-		pipe.Expire(ctx, tagKey, 12*time.Hour)
-		_, _ = pipe.Exec(ctx)
+		// 2c. Save to Redis with pipeline
+		setTagged(cacheKey, "movie_list", result)
 
-		return apiResponse, nil
+		return result, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return rawResult.(map[string]interface{}), nil
+	return applyListOverrides(ctx, rawResult.(*movies.MovieListResponse)), nil
 }
 
 // GetSearchMovies performs a search query using phimapi.com and caches the response
-func GetSearchMovies(req movies.MovieSearchRequest) (map[string]interface{}, error) {
-	rdb := config.RDB
-	ctx := config.Ctx
+func GetSearchMovies(ctx context.Context, req movies.MovieSearchRequest) (*movies.MovieSearchResponse, error) {
+	if err := categoryHiddenError(ctx, req.Category); err != nil {
+		return nil, err
+	}
 
 	targetURL := buildSearchURL(req)
 	cacheKey := buildSearchCacheKey(req)
 
 	// 1. Redis cache first
-	if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil && len(cached) > 0 {
-		var result map[string]interface{}
-		if jsonErr := json.Unmarshal(cached, &result); jsonErr == nil {
-			result["from_cache"] = true
-			return result, nil
-		}
+	var cached movies.MovieSearchResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applySearchOverrides(ctx, &cached), nil
 	}
 
 	// 2. Fetch from upstream
-	responseBody, err := MakeAnonymousRequest(targetURL)
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch search results from API: %w", err)
+		return nil, upstreamError("search results from API", err)
 	}
 
-	var raw map[string]interface{}
-	if err := json.Unmarshal(responseBody, &raw); err != nil {
-		return map[string]interface{}{
-			"success":  false,
-			"error":    "Failed to parse API response",
-			"raw_data": string(responseBody),
-		}, nil
+	// 3. Parse về dạng typed
+	items, pagination, err := decodeUpstreamList(ctx, responseBody)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Normalize response
-	normalized, ok := utils.IsValidApiResponse(raw)
-	if !ok {
-		return map[string]interface{}{
-			"success": false,
-			"error":   "Unexpected response structure, skip caching",
-		}, nil
+	// 4. Metadata
+	result := &movies.MovieSearchResponse{
+		Keyword:    req.Keyword,
+		Items:      items,
+		Pagination: pagination,
+		Meta: utils.Meta{
+			FromCache:  false,
+			RequestURL: targetURL,
+			Timestamp:  time.Now().Unix(),
+		},
 	}
 
-	// 4. Metadata
-	normalized["from_cache"] = false
-	normalized["request_url"] = targetURL
-	normalized["timestamp"] = time.Now().Unix()
-
-	// 5. Marshal once
-	jsonStr, _ := json.Marshal(normalized)
-
-	// 6. Cache with shorter TTL for search
-	ttl := 8 * time.Hour
-	tagKey := "movie_search:cached_keys"
-
-	pipe := rdb.Pipeline()
-	pipe.Set(ctx, cacheKey, jsonStr, ttl)
-	pipe.SAdd(ctx, tagKey, cacheKey)
-	pipe.Expire(ctx, tagKey, 12*time.Hour)
-	_, _ = pipe.Exec(ctx)
-
-	// 7. Return final map
-	var returnMap map[string]interface{}
-	_ = json.Unmarshal(jsonStr, &returnMap)
-	return returnMap, nil
+	// 5. Cache with shorter TTL for search
+	setTagged(cacheKey, "movie_search", result)
+
+	return applySearchOverrides(ctx, result), nil
 }
 
 // buildListURL constructs the target API URL with query params
@@ -0,0 +1,491 @@
+package movies
+
+import (
+	"ani4s/src/cache"
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+var recommendLog = logger.Named("recommend")
+
+// Similar titles blend two signals, rebuilt by RebuildRecommendations:
+//   - content: shared categories, countries, actors and directors, each feature weighted
+//     by its rarity (IDF) so a shared director counts more than a shared "Hành Động",
+//     plus a small bonus for close release years and well rated titles;
+//   - co-watch: movies played by the same users (history:user:<id>).
+//
+// The top neighbours of each movie are stored in recs:similar:<slug>, rebuilt every
+// jobs.recommend_interval.
+const (
+	similarPrefix = "recs:similar:"
+	historyPrefix = "history:user:"
+
+	similarSize     = 48  // neighbours stored per movie
+	candidateCap    = 400 // movies read from each feature's posting list, newest first
+	historyLimit    = 100 // movies kept per user history
+	historyTTL      = 90 * 24 * time.Hour
+	coWatchDepth    = 30 // latest movies of a history paired together
+	minCoWatch      = 2  // users who must share a pair before it counts
+	coWatchWeight   = 1.0
+	becauseSections = 3
+
+	yearBonus = 0.1  // same year, fading to nothing 10 years apart
+	voteBonus = 0.05 // TMDB 10/10
+)
+
+// placeholderPeople are the actor/director values phimapi uses for "unknown"
+var placeholderPeople = map[string]bool{
+	"":              true,
+	"đang cập nhật": true,
+	"updating":      true,
+	"n/a":           true,
+}
+
+type neighbour struct {
+	slug  string
+	score float64
+}
+
+func historyKey(userID uint) string {
+	return historyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// RecordWatch adds slug to the watch history of userID
+func RecordWatch(ctx context.Context, userID uint, slug string) {
+	rdb := config.RDB
+	key := historyKey(userID)
+
+	pipe := rdb.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().Unix()), Member: slug})
+	pipe.ZRemRangeByRank(ctx, key, 0, -historyLimit-1)
+	pipe.Expire(ctx, key, historyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		recommendLog.Error(ctx, "failed to record watch history", "user_id", userID, "slug", slug, "error", err)
+	}
+}
+
+// RebuildRecommendations recomputes the neighbours of every stored movie and returns how many were indexed
+func RebuildRecommendations() (int, error) {
+	// 1. Content similarity over the mirrored catalog
+	content, err := contentNeighbours()
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. Co-watch signal from the histories
+	coWatch, err := coWatchNeighbours()
+	if err != nil {
+		return 0, err
+	}
+
+	// 3. Blend and store
+	rdb := config.RDB
+	ctx := config.Ctx
+	slugs := make(map[string]bool, len(content)+len(coWatch))
+	for slug := range content {
+		slugs[slug] = true
+	}
+	for slug := range coWatch {
+		slugs[slug] = true
+	}
+
+	pipe := rdb.Pipeline()
+	queued := 0
+	for slug := range slugs {
+		scores := map[string]float64{}
+		for _, n := range content[slug] {
+			scores[n.slug] += n.score
+		}
+		for other, score := range coWatch[slug] {
+			scores[other] += coWatchWeight * score
+		}
+		top := topNeighbours(scores)
+		if len(top) == 0 {
+			continue
+		}
+
+		key := similarPrefix + slug
+		members := make([]redis.Z, 0, len(top))
+		for _, n := range top {
+			members = append(members, redis.Z{Score: n.score, Member: n.slug})
+		}
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, 2*config.App.Jobs.RecommendInterval)
+		queued++
+		if queued%200 == 0 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return 0, fmt.Errorf("failed to store neighbours: %w", err)
+			}
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to store neighbours: %w", err)
+	}
+
+	// 4. Cached similar lists are stale now
+	if _, err := FlushTag("movie_similar"); err != nil {
+		return queued, err
+	}
+	return queued, nil
+}
+
+func topNeighbours(scores map[string]float64) []neighbour {
+	out := make([]neighbour, 0, len(scores))
+	for slug, score := range scores {
+		out = append(out, neighbour{slug, score})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].score != out[j].score {
+			return out[i].score > out[j].score
+		}
+		return out[i].slug < out[j].slug
+	})
+	if len(out) > similarSize {
+		out = out[:similarSize]
+	}
+	return out
+}
+
+// contentNeighbours scores every movie against the ones sharing a feature with it
+func contentNeighbours() (map[string][]neighbour, error) {
+	// 1. Movies, newest first so truncated posting lists keep recent titles
+	var docs []struct {
+		Slug        string
+		Year        int
+		VoteAverage float64
+		Actor       pq.StringArray `gorm:"type:text[]"`
+		Director    pq.StringArray `gorm:"type:text[]"`
+	}
+	if err := config.DB.Model(&movies.Movie{}).
+		Select("slug, year, vote_average, actor, director").
+		Order("modified_time DESC").
+		Scan(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load movies: %w", err)
+	}
+	categories, err := categoryTaxonomy.slugsOfMovies(config.DB, nil)
+	if err != nil {
+		return nil, err
+	}
+	countries, err := countryTaxonomy.slugsOfMovies(config.DB, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Features and posting lists
+	featureIDs := map[string]int32{}
+	var postings [][]int32
+	features := make([][]int32, len(docs))
+	add := func(doc int, feature string) {
+		id, ok := featureIDs[feature]
+		if !ok {
+			id = int32(len(postings))
+			featureIDs[feature] = id
+			postings = append(postings, nil)
+		}
+		postings[id] = append(postings[id], int32(doc))
+		features[doc] = append(features[doc], id)
+	}
+	for i, d := range docs {
+		seen := map[string]bool{}
+		feature := func(f string) {
+			if !seen[f] {
+				seen[f] = true
+				add(i, f)
+			}
+		}
+		for _, slug := range categories[d.Slug] {
+			feature("c:" + slug)
+		}
+		for _, slug := range countries[d.Slug] {
+			feature("n:" + slug)
+		}
+		for _, name := range d.Actor {
+			if name = strings.ToLower(strings.TrimSpace(name)); !placeholderPeople[name] {
+				feature("a:" + name)
+			}
+		}
+		for _, name := range d.Director {
+			if name = strings.ToLower(strings.TrimSpace(name)); !placeholderPeople[name] {
+				feature("d:" + name)
+			}
+		}
+	}
+
+	// 3. IDF² weights and the norm of each movie
+	total := float64(len(docs))
+	weights := make([]float64, len(postings))
+	for id, list := range postings {
+		idf := math.Log(total / float64(len(list)))
+		weights[id] = idf * idf
+	}
+	norms := make([]float64, len(docs))
+	for i, feats := range features {
+		for _, f := range feats {
+			norms[i] += weights[f]
+		}
+		norms[i] = math.Sqrt(norms[i])
+	}
+
+	// 4. Weighted cosine against the candidates sharing a feature
+	out := make(map[string][]neighbour, len(docs))
+	acc := make([]float64, len(docs))
+	var touched []int32
+	for i, feats := range features {
+		if norms[i] == 0 {
+			continue
+		}
+		for _, f := range feats {
+			w := weights[f]
+			if w == 0 {
+				continue
+			}
+			list := postings[f]
+			if len(list) > candidateCap {
+				list = list[:candidateCap]
+			}
+			for _, j := range list {
+				if int(j) == i {
+					continue
+				}
+				if acc[j] == 0 {
+					touched = append(touched, j)
+				}
+				acc[j] += w
+			}
+		}
+
+		scores := make(map[string]float64, len(touched))
+		for _, j := range touched {
+			score := acc[j] / (norms[i] * norms[j])
+			if docs[i].Year > 0 && docs[j].Year > 0 {
+				gap := math.Abs(float64(docs[i].Year - docs[j].Year))
+				score += yearBonus * math.Max(0, 1-gap/10)
+			}
+			score += voteBonus * docs[j].VoteAverage / 10
+			scores[docs[j].Slug] = score
+			acc[j] = 0
+		}
+		touched = touched[:0]
+
+		if top := topNeighbours(scores); len(top) > 0 {
+			out[docs[i].Slug] = top
+		}
+	}
+	return out, nil
+}
+
+// coWatchNeighbours returns, for each movie, the cosine of its co-occurrence with the others in histories
+func coWatchNeighbours() (map[string]map[string]float64, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	watchers := map[string]float64{}
+	pairs := map[string]map[string]float64{}
+
+	err := cache.ScanKeys(ctx, rdb, historyPrefix+"*", func(key string) error {
+		slugs, err := rdb.ZRevRange(ctx, key, 0, coWatchDepth-1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+		for i, a := range slugs {
+			watchers[a]++
+			for j, b := range slugs {
+				if i == j {
+					continue
+				}
+				if pairs[a] == nil {
+					pairs[a] = map[string]float64{}
+				}
+				pairs[a][b]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan histories: %w", err)
+	}
+
+	out := make(map[string]map[string]float64, len(pairs))
+	for a, others := range pairs {
+		for b, together := range others {
+			if together < minCoWatch {
+				continue
+			}
+			if out[a] == nil {
+				out[a] = map[string]float64{}
+			}
+			out[a][b] = together / math.Sqrt(watchers[a]*watchers[b])
+		}
+	}
+	return out, nil
+}
+
+// orderedItems returns the stored movies of slugs in that order, skipping unknown slugs
+func orderedItems(ctx context.Context, slugs []string) ([]lib.MovieItem, error) {
+	bySlug, err := storedMovies(ctx, slugs)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]lib.MovieItem, 0, len(slugs))
+	for _, slug := range slugs {
+		if m, ok := bySlug[slug]; ok {
+			items = append(items, movieItemFromModel(m))
+		}
+	}
+	return items, nil
+}
+
+// GetSimilar returns the titles most similar to slug
+func GetSimilar(ctx context.Context, slug string, limit int) (*lib.SimilarResponse, error) {
+	if err := movieHiddenError(ctx, slug); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("movie_similar:%s:%d", slug, limit)
+
+	// 1. Redis cache
+	var cached lib.SimilarResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applySimilarOverrides(ctx, &cached), nil
+	}
+
+	// 2. Precomputed neighbours
+	slugs, err := config.RDB.ZRevRange(config.Ctx, similarPrefix+slug, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read neighbours: %w", err)
+	}
+	var items []lib.MovieItem
+	if len(slugs) > 0 {
+		if items, err = orderedItems(ctx, slugs); err != nil {
+			return nil, err
+		}
+	} else {
+		// Not indexed yet: newest titles of its first category
+		if items, err = sameCategoryItems(ctx, slug, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &lib.SimilarResponse{
+		Slug:  slug,
+		Items: items,
+		Meta:  utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}
+	setTagged(cacheKey, "movie_similar", result)
+
+	return applySimilarOverrides(ctx, result), nil
+}
+
+// sameCategoryItems lists the newest stored movies sharing the first category of slug
+func sameCategoryItems(ctx context.Context, slug string, limit int) ([]lib.MovieItem, error) {
+	detail, err := GetDetailsMovie(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	items := []lib.MovieItem{}
+	if len(detail.Movie.Categories) == 0 {
+		return items, nil
+	}
+
+	local, err := ListMoviesFromDB(ctx, lib.MoviesByCategoryRequest{
+		Category:  detail.Movie.Categories[0].Slug,
+		Page:      1,
+		Limit:     limit + 1,
+		SortField: "modified.time",
+		SortType:  "desc",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range local.Items {
+		if it.Slug != slug && len(items) < limit {
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+func applySimilarOverrides(ctx context.Context, res *lib.SimilarResponse) *lib.SimilarResponse {
+	out := *res
+	out.Items = currentOverrides(ctx).items(res.Items)
+	return &out
+}
+
+// GetRecommendations builds "Because you watched" rows from the latest movies of a user's history
+func GetRecommendations(ctx context.Context, userID uint, limit int) (*lib.RecommendationsResponse, error) {
+	rdb := config.RDB
+
+	result := &lib.RecommendationsResponse{
+		UserID:   userID,
+		Sections: []lib.RecommendationSection{},
+		Meta:     utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}
+
+	history, err := rdb.ZRevRange(ctx, historyKey(userID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	// Never recommend what was watched, nor the same title in two rows
+	excluded := make(map[string]bool, len(history))
+	for _, slug := range history {
+		excluded[slug] = true
+	}
+
+	o := currentOverrides(ctx)
+	for _, watched := range history {
+		if len(result.Sections) == becauseSections {
+			break
+		}
+		neighbours, err := rdb.ZRevRange(ctx, similarPrefix+watched, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read neighbours: %w", err)
+		}
+		picks := make([]string, 0, limit)
+		for _, slug := range neighbours {
+			if !excluded[slug] && len(picks) < limit {
+				picks = append(picks, slug)
+			}
+		}
+		if len(picks) == 0 {
+			continue
+		}
+
+		because, err := orderedItems(ctx, []string{watched})
+		if err != nil {
+			return nil, err
+		}
+		because = o.items(because)
+		if len(because) == 0 {
+			continue
+		}
+		items, err := orderedItems(ctx, picks)
+		if err != nil {
+			return nil, err
+		}
+		items = o.items(items)
+		if len(items) == 0 {
+			continue
+		}
+
+		for _, it := range items {
+			excluded[it.Slug] = true
+		}
+		result.Sections = append(result.Sections, lib.RecommendationSection{Because: because[0], Items: items})
+	}
+	return result, nil
+}
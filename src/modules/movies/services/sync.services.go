@@ -0,0 +1,281 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	movies "ani4s/src/modules/movies/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// movieColumns lists the columns refreshed from upstream with their values.
+// View is local and images are owned by the image sync job once mirrored.
+func movieColumns(m *movies.Movie) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            m.Name,
+		"origin_name":     m.OriginName,
+		"content":         m.Content,
+		"type":            m.Type,
+		"status":          m.Status,
+		"is_copyright":    m.IsCopyright,
+		"sub_doc_quyen":   m.SubDocQuyen,
+		"chieu_rap":       m.ChieuRap,
+		"trailer_url":     m.TrailerURL,
+		"time":            m.Time,
+		"episode_current": m.EpisodeCurrent,
+		"episode_total":   m.EpisodeTotal,
+		"quality":         m.Quality,
+		"lang":            m.Lang,
+		"notify":          m.Notify,
+		"showtimes":       m.Showtimes,
+		"year":            m.Year,
+		"actor":           m.Actor,
+		"director":        m.Director,
+		"modified_time":   m.Modified.Time,
+	}
+}
+
+// isUpstreamImage reports whether an image column still points at phimapi (not mirrored yet)
+func isUpstreamImage(path string) bool {
+	return path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// SaveMovieDetails syncs one upstream payload into the DB: new movies are inserted,
+// stored ones are updated column by column when upstream reports a newer Modified.Time,
+// categories/countries are re-linked and episodes are added/updated/removed per server. Every difference is written to
+// the movie_changes log. It reports whether anything changed.
+func SaveMovieDetails(ctx context.Context, data *movies.MovieDetails) (bool, error) {
+	incoming := &data.Movie
+	now := time.Now()
+	var changes []movies.MovieChange
+
+	tx := config.DB.WithContext(ctx).Begin()
+
+	// 1. Movie row
+	var existing movies.Movie
+	err := tx.Where("id = ?", incoming.ID).Take(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Omit(clause.Associations).Create(incoming).Error; err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("failed to insert movie: %w", err)
+		}
+		changes = append(changes, movies.MovieChange{
+			MovieID:   incoming.ID,
+			Kind:      movies.ChangeCreated,
+			NewValue:  incoming.Slug,
+			ChangedAt: now,
+		})
+
+	case err != nil:
+		tx.Rollback()
+		return false, fmt.Errorf("failed to load movie: %w", err)
+
+	default:
+		// Upstream not modified since the last sync: nothing to do
+		if !existing.Modified.Time.IsZero() && !incoming.Modified.Time.After(existing.Modified.Time) {
+			tx.Rollback()
+			return false, nil
+		}
+
+		oldCols := movieColumns(&existing)
+		updates := map[string]interface{}{}
+		for column, value := range movieColumns(incoming) {
+			if changed, oldValue, newValue := diffValue(oldCols[column], value); changed {
+				updates[column] = value
+				changes = append(changes, movies.MovieChange{
+					MovieID:   incoming.ID,
+					Kind:      movies.ChangeUpdated,
+					Field:     column,
+					OldValue:  oldValue,
+					NewValue:  newValue,
+					ChangedAt: now,
+				})
+			}
+		}
+		for column, pair := range map[string][2]string{
+			"poster_url": {existing.PosterURL, incoming.PosterURL},
+			"thumb_url":  {existing.ThumbURL, incoming.ThumbURL},
+		} {
+			if isUpstreamImage(pair[0]) && pair[0] != pair[1] {
+				updates[column] = pair[1]
+				changes = append(changes, movies.MovieChange{
+					MovieID:   incoming.ID,
+					Kind:      movies.ChangeUpdated,
+					Field:     column,
+					OldValue:  pair[0],
+					NewValue:  pair[1],
+					ChangedAt: now,
+				})
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&movies.Movie{}).Where("id = ?", incoming.ID).Updates(updates).Error; err != nil {
+				tx.Rollback()
+				return false, fmt.Errorf("failed to update movie: %w", err)
+			}
+		}
+	}
+
+	// 2. Categories / countries
+	taxonomyChanges, err := syncMovieTaxonomies(tx, incoming, now)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	// 3. Episodes
+	episodeChanges, err := syncEpisodes(tx, incoming.ID, data.Episodes, now)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	// A brand new movie only logs "created", not each of its relations and episodes
+	if len(changes) == 0 || changes[0].Kind != movies.ChangeCreated {
+		changes = append(changes, taxonomyChanges...)
+		changes = append(changes, episodeChanges...)
+	}
+
+	// 4. Change log
+	if len(changes) > 0 {
+		if err := tx.Create(&changes).Error; err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("failed to write change log: %w", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("transaction commit failed: %w", err)
+	}
+	return len(changes) > 0, nil
+}
+
+// syncEpisodes makes the stored episodes of movieID match groups, keyed by server name and slug
+func syncEpisodes(tx *gorm.DB, movieID string, groups []movies.EpisodeGroup, now time.Time) ([]movies.MovieChange, error) {
+	// Upstream sometimes answers without episodes: keep what we have rather than wiping it
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	var stored []movies.Episode
+	if err := tx.Where("movie_id = ?", movieID).Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load episodes: %w", err)
+	}
+	existing := make(map[string]movies.Episode, len(stored))
+	for _, ep := range stored {
+		existing[ep.ServerName+"/"+ep.Slug] = ep
+	}
+
+	var changes []movies.MovieChange
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		for _, ep := range group.ServerData {
+			key := group.ServerName + "/" + ep.Slug
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			old, ok := existing[key]
+			if !ok {
+				ep.ID = 0
+				ep.MovieID = movieID
+				ep.Movie = movies.Movie{}
+				ep.ServerName = group.ServerName
+				if err := tx.Omit("Movie").Create(&ep).Error; err != nil {
+					return nil, fmt.Errorf("failed to insert episode %s: %w", key, err)
+				}
+				changes = append(changes, movies.MovieChange{
+					MovieID:   movieID,
+					Kind:      movies.ChangeEpisodeAdded,
+					Field:     key,
+					NewValue:  ep.LinkM3U8,
+					ChangedAt: now,
+				})
+				continue
+			}
+			delete(existing, key)
+
+			updates := map[string]interface{}{}
+			for column, pair := range map[string][2]string{
+				"name":       {old.Name, ep.Name},
+				"filename":   {old.Filename, ep.Filename},
+				"link_embed": {old.LinkEmbed, ep.LinkEmbed},
+				"link_m3_u8": {old.LinkM3U8, ep.LinkM3U8},
+			} {
+				if pair[0] != pair[1] {
+					updates[column] = pair[1]
+					changes = append(changes, movies.MovieChange{
+						MovieID:   movieID,
+						Kind:      movies.ChangeEpisodeUpdated,
+						Field:     key + ":" + column,
+						OldValue:  pair[0],
+						NewValue:  pair[1],
+						ChangedAt: now,
+					})
+				}
+			}
+			if len(updates) > 0 {
+				if err := tx.Model(&movies.Episode{}).Where("id = ?", old.ID).Updates(updates).Error; err != nil {
+					return nil, fmt.Errorf("failed to update episode %s: %w", key, err)
+				}
+			}
+		}
+	}
+
+	// Whatever is left is gone upstream
+	for key, old := range existing {
+		if err := tx.Delete(&movies.Episode{}, old.ID).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove episode %s: %w", key, err)
+		}
+		changes = append(changes, movies.MovieChange{
+			MovieID:   movieID,
+			Kind:      movies.ChangeEpisodeRemoved,
+			Field:     key,
+			OldValue:  old.LinkM3U8,
+			ChangedAt: now,
+		})
+	}
+	return changes, nil
+}
+
+// diffValue compares two column values and returns their printable forms
+func diffValue(oldValue, newValue interface{}) (bool, string, string) {
+	if o, ok := oldValue.(time.Time); ok {
+		n, _ := newValue.(time.Time)
+		return !o.Equal(n), o.Format(time.RFC3339), n.Format(time.RFC3339)
+	}
+	o, n := fmt.Sprint(oldValue), fmt.Sprint(newValue)
+	return o != n, o, n
+}
+
+// RefreshMovieIfModified re-syncs slug from upstream when modified is newer than the stored copy
+func RefreshMovieIfModified(ctx context.Context, slug string, modified time.Time) error {
+	var stored movies.Movie
+	err := config.DB.WithContext(ctx).Select("id", "modified_time").Where("slug = ?", slug).Take(&stored).Error
+	if err == nil && !stored.Modified.Time.IsZero() && !modified.After(stored.Modified.Time) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	data, err := FetchMovieDetails(ctx, slug)
+	if err != nil {
+		return err
+	}
+	changed, err := SaveMovieDetails(ctx, data)
+	if err != nil {
+		return err
+	}
+	if changed {
+		InvalidateMovieDetails(slug)
+	}
+	return nil
+}
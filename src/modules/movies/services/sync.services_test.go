@@ -0,0 +1,174 @@
+package movies
+
+import (
+	movies "ani4s/src/modules/movies/models"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestDiffValue(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		oldValue, newValue interface{}
+		changed            bool
+		oldText, newText   string
+	}{
+		{"same string", "Ongoing", "Ongoing", false, "Ongoing", "Ongoing"},
+		{"string", "Ongoing", "Completed", true, "Ongoing", "Completed"},
+		{"int", 2023, 2024, true, "2023", "2024"},
+		{"bool", false, false, false, "false", "false"},
+		{"same instant in another zone", at, at.In(time.FixedZone("ICT", 7*3600)), false, "2024-05-01T12:00:00Z", "2024-05-01T19:00:00+07:00"},
+		{"later time", at, at.Add(time.Hour), true, "2024-05-01T12:00:00Z", "2024-05-01T13:00:00Z"},
+		{"zero time", time.Time{}, at, true, "0001-01-01T00:00:00Z", "2024-05-01T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, o, n := diffValue(tt.oldValue, tt.newValue)
+			if changed != tt.changed || o != tt.oldText || n != tt.newText {
+				t.Errorf("diffValue(%v, %v) = %v, %q, %q, want %v, %q, %q",
+					tt.oldValue, tt.newValue, changed, o, n, tt.changed, tt.oldText, tt.newText)
+			}
+		})
+	}
+}
+
+// newEpisodeDB opens an in-memory database holding the episodes of movie "m1"
+func newEpisodeDB(t *testing.T, stored []movies.Episode) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE episodes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		movie_id TEXT NOT NULL,
+		server_name TEXT,
+		name TEXT,
+		slug TEXT,
+		filename TEXT,
+		link_embed TEXT,
+		link_m3_u8 TEXT,
+		UNIQUE (movie_id, server_name, slug)
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, ep := range stored {
+		ep.MovieID = "m1"
+		if err := db.Omit("Movie").Create(&ep).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func episode(server, slug, link string) movies.Episode {
+	return movies.Episode{ServerName: server, Name: slug, Slug: slug, LinkM3U8: link}
+}
+
+func TestSyncEpisodes(t *testing.T) {
+	now := time.Now()
+	stored := []movies.Episode{
+		episode("Vietsub", "tap-1", "v1.m3u8"),
+		episode("Vietsub", "tap-2", "v2.m3u8"),
+		episode("Thuyet Minh", "tap-1", "t1.m3u8"),
+	}
+
+	tests := []struct {
+		name    string
+		groups  []movies.EpisodeGroup
+		changes []string // kind and field of every change, sorted
+		want    []string // server/slug/link of the stored episodes, sorted
+	}{
+		{
+			name:   "no groups keeps the stored episodes",
+			groups: nil,
+			want:   []string{"Thuyet Minh/tap-1/t1.m3u8", "Vietsub/tap-1/v1.m3u8", "Vietsub/tap-2/v2.m3u8"},
+		},
+		{
+			name: "unchanged",
+			groups: []movies.EpisodeGroup{
+				{ServerName: "Vietsub", ServerData: []movies.Episode{episode("", "tap-1", "v1.m3u8"), episode("", "tap-2", "v2.m3u8")}},
+				{ServerName: "Thuyet Minh", ServerData: []movies.Episode{episode("", "tap-1", "t1.m3u8")}},
+			},
+			want: []string{"Thuyet Minh/tap-1/t1.m3u8", "Vietsub/tap-1/v1.m3u8", "Vietsub/tap-2/v2.m3u8"},
+		},
+		{
+			name: "added, updated and removed",
+			groups: []movies.EpisodeGroup{
+				{ServerName: "Vietsub", ServerData: []movies.Episode{
+					episode("", "tap-1", "v1-new.m3u8"),
+					episode("", "tap-2", "v2.m3u8"),
+					episode("", "tap-3", "v3.m3u8"),
+				}},
+			},
+			changes: []string{
+				"episode_added Vietsub/tap-3",
+				"episode_removed Thuyet Minh/tap-1",
+				"episode_updated Vietsub/tap-1:link_m3_u8",
+			},
+			want: []string{"Vietsub/tap-1/v1-new.m3u8", "Vietsub/tap-2/v2.m3u8", "Vietsub/tap-3/v3.m3u8"},
+		},
+		{
+			name: "same slug on another server is another episode",
+			groups: []movies.EpisodeGroup{
+				{ServerName: "Vietsub", ServerData: []movies.Episode{episode("", "tap-1", "v1.m3u8"), episode("", "tap-2", "v2.m3u8")}},
+				{ServerName: "Thuyet Minh", ServerData: []movies.Episode{episode("", "tap-1", "t1.m3u8"), episode("", "tap-2", "t2.m3u8")}},
+			},
+			changes: []string{"episode_added Thuyet Minh/tap-2"},
+			want:    []string{"Thuyet Minh/tap-1/t1.m3u8", "Thuyet Minh/tap-2/t2.m3u8", "Vietsub/tap-1/v1.m3u8", "Vietsub/tap-2/v2.m3u8"},
+		},
+		{
+			name: "duplicates upstream are synced once",
+			groups: []movies.EpisodeGroup{
+				{ServerName: "Vietsub", ServerData: []movies.Episode{
+					episode("", "tap-1", "v1.m3u8"),
+					episode("", "tap-2", "v2.m3u8"),
+					episode("", "tap-2", "v2-dup.m3u8"),
+				}},
+				{ServerName: "Thuyet Minh", ServerData: []movies.Episode{episode("", "tap-1", "t1.m3u8")}},
+			},
+			want: []string{"Thuyet Minh/tap-1/t1.m3u8", "Vietsub/tap-1/v1.m3u8", "Vietsub/tap-2/v2.m3u8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newEpisodeDB(t, stored)
+
+			changes, err := syncEpisodes(db, "m1", tt.groups, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range changes {
+				if c.MovieID != "m1" || !c.ChangedAt.Equal(now) {
+					t.Errorf("change %+v is not stamped with the movie and sync time", c)
+				}
+				got = append(got, c.Kind+" "+c.Field)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.changes) {
+				t.Errorf("changes = %q, want %q", got, tt.changes)
+			}
+
+			var eps []movies.Episode
+			if err := db.Where("movie_id = ?", "m1").Find(&eps).Error; err != nil {
+				t.Fatal(err)
+			}
+			var rows []string
+			for _, ep := range eps {
+				rows = append(rows, ep.ServerName+"/"+ep.Slug+"/"+ep.LinkM3U8)
+			}
+			slices.Sort(rows)
+			if !slices.Equal(rows, tt.want) {
+				t.Errorf("episodes = %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// taxonomyRow is the shared shape of the categories and countries tables
+type taxonomyRow struct {
+	ID   string
+	Name string
+	Slug string
+}
+
+// taxonomy describes one many2many relation of Movie
+type taxonomy struct {
+	table     string // categories / countries
+	joinTable string // movie_categories / movie_countries
+	joinFK    string // category_id / country_id
+}
+
+var (
+	categoryTaxonomy = taxonomy{table: "categories", joinTable: "movie_categories", joinFK: "category_id"}
+	countryTaxonomy  = taxonomy{table: "countries", joinTable: "movie_countries", joinFK: "country_id"}
+)
+
+func categoryRows(items []movies.Category) []taxonomyRow {
+	rows := make([]taxonomyRow, 0, len(items))
+	for _, c := range items {
+		rows = append(rows, taxonomyRow{ID: c.ID, Name: c.Name, Slug: c.Slug})
+	}
+	return rows
+}
+
+func countryRows(items []movies.Country) []taxonomyRow {
+	rows := make([]taxonomyRow, 0, len(items))
+	for _, c := range items {
+		rows = append(rows, taxonomyRow{ID: c.ID, Name: c.Name, Slug: c.Slug})
+	}
+	return rows
+}
+
+// upsert inserts rows keyed by slug (refreshing the name) and returns the stored ID of each slug
+func (t taxonomy) upsert(tx *gorm.DB, rows []taxonomyRow) (map[string]string, error) {
+	seen := make(map[string]bool, len(rows))
+	var clean []taxonomyRow
+	var slugs []string
+	for _, r := range rows {
+		if r.Slug == "" || seen[r.Slug] {
+			continue
+		}
+		seen[r.Slug] = true
+		if r.ID == "" {
+			r.ID = r.Slug
+		}
+		clean = append(clean, r)
+		slugs = append(slugs, r.Slug)
+	}
+	ids := make(map[string]string, len(clean))
+	if len(clean) == 0 {
+		return ids, nil
+	}
+
+	if err := tx.Table(t.table).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}).Create(&clean).Error; err != nil {
+		return nil, fmt.Errorf("failed to upsert %s: %w", t.table, err)
+	}
+
+	var stored []taxonomyRow
+	if err := tx.Table(t.table).Where("slug IN ?", slugs).Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.table, err)
+	}
+	for _, r := range stored {
+		ids[r.Slug] = r.ID
+	}
+	return ids, nil
+}
+
+// syncMovie makes the join rows of movieID match rows and returns a change entry when they differ
+func (t taxonomy) syncMovie(tx *gorm.DB, movieID string, rows []taxonomyRow, now time.Time) (*movies.MovieChange, error) {
+	// Upstream sometimes answers without relations: keep the stored ones
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids, err := t.upsert(tx, rows)
+	if err != nil {
+		return nil, err
+	}
+	newSlugs := make([]string, 0, len(ids))
+	for slug := range ids {
+		newSlugs = append(newSlugs, slug)
+	}
+	sort.Strings(newSlugs)
+
+	var oldSlugs []string
+	if err := tx.Table(t.table).
+		Joins(fmt.Sprintf("JOIN %s j ON j.%s = %s.id", t.joinTable, t.joinFK, t.table)).
+		Where("j.movie_id = ?", movieID).
+		Order(t.table+".slug").
+		Pluck(t.table+".slug", &oldSlugs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s of movie: %w", t.table, err)
+	}
+
+	oldValue, newValue := strings.Join(oldSlugs, ","), strings.Join(newSlugs, ",")
+	if oldValue == newValue {
+		return nil, nil
+	}
+
+	if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE movie_id = ?", t.joinTable), movieID).Error; err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", t.joinTable, err)
+	}
+	links := make([]map[string]interface{}, 0, len(newSlugs))
+	for _, slug := range newSlugs {
+		links = append(links, map[string]interface{}{"movie_id": movieID, t.joinFK: ids[slug]})
+	}
+	if err := tx.Table(t.joinTable).Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to link %s: %w", t.table, err)
+	}
+
+	return &movies.MovieChange{
+		MovieID:   movieID,
+		Kind:      movies.ChangeUpdated,
+		Field:     t.table,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		ChangedAt: now,
+	}, nil
+}
+
+// syncMovieTaxonomies links a saved movie to its categories and countries
+func syncMovieTaxonomies(tx *gorm.DB, movie *movies.Movie, now time.Time) ([]movies.MovieChange, error) {
+	var changes []movies.MovieChange
+	for _, rel := range []struct {
+		t    taxonomy
+		rows []taxonomyRow
+	}{
+		{categoryTaxonomy, categoryRows(movie.Categories)},
+		{countryTaxonomy, countryRows(movie.Countries)},
+	} {
+		change, err := rel.t.syncMovie(tx, movie.ID, rel.rows, now)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// slugsOfMovies maps each movie slug to the slugs of its categories or countries; nil loads every movie
+func (t taxonomy) slugsOfMovies(tx *gorm.DB, movieSlugs []string) (map[string][]string, error) {
+	var rows []struct {
+		MovieSlug string
+		Slug      string
+	}
+	q := tx.Table("movies").
+		Select(fmt.Sprintf("movies.slug AS movie_slug, %s.slug AS slug", t.table)).
+		Joins(fmt.Sprintf("JOIN %s j ON j.movie_id = movies.id", t.joinTable)).
+		Joins(fmt.Sprintf("JOIN %s ON %s.id = j.%s", t.table, t.table, t.joinFK))
+	if movieSlugs != nil {
+		q = q.Where("movies.slug IN ?", movieSlugs)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load %s of movies: %w", t.table, err)
+	}
+
+	out := make(map[string][]string, len(movieSlugs))
+	for _, r := range rows {
+		out[r.MovieSlug] = append(out[r.MovieSlug], r.Slug)
+	}
+	return out, nil
+}
+
+// ListCategoriesFromDB returns the categories stored locally, used when phimapi is unreachable
+func ListCategoriesFromDB(ctx context.Context) (*lib.CategoryListResponse, error) {
+	var items []movies.Category
+	if err := config.DB.WithContext(ctx).Order("name").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	return &lib.CategoryListResponse{
+		Items: items,
+		Meta:  utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}, nil
+}
+
+// ListCountriesFromDB returns the countries stored locally, used when phimapi is unreachable
+func ListCountriesFromDB(ctx context.Context) (*lib.CountryListResponse, error) {
+	var items []movies.Country
+	if err := config.DB.WithContext(ctx).Order("name").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load countries: %w", err)
+	}
+	return &lib.CountryListResponse{
+		Items: items,
+		Meta:  utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}, nil
+}
+
+// localSortColumns maps the phimapi sort fields to movie columns
+var localSortColumns = map[string]string{
+	"modified.time": "modified_time",
+	"_id":           "id",
+	"year":          "year",
+}
+
+// localLangs maps sort_lang to the text stored in movies.lang
+var localLangs = map[string]string{
+	"vietsub":     "vietsub",
+	"thuyet-minh": "thuyết minh",
+	"long-tieng":  "lồng tiếng",
+}
+
+// ListMoviesFromDB answers a the-loai / quoc-gia listing from the mirrored catalog.
+// Both Category and Country of req are applied as filters when set.
+func ListMoviesFromDB(ctx context.Context, req lib.MoviesByCategoryRequest) (*lib.MovieListResponse, error) {
+	db := config.DB.WithContext(ctx)
+
+	// 1. Filters
+	q := db.Model(&movies.Movie{})
+	if req.Category != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM movie_categories mc JOIN categories c ON c.id = mc.category_id WHERE mc.movie_id = movies.id AND c.slug = ?)", req.Category)
+	}
+	if req.Country != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM movie_countries mc JOIN countries c ON c.id = mc.country_id WHERE mc.movie_id = movies.id AND c.slug = ?)", req.Country)
+	}
+	if req.Year != 0 {
+		q = q.Where("year = ?", req.Year)
+	}
+	if lang, ok := localLangs[req.SortLang]; ok {
+		q = q.Where("LOWER(lang) LIKE ?", "%"+lang+"%")
+	}
+
+	// 2. Count + page
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count movies: %w", err)
+	}
+
+	column, ok := localSortColumns[req.SortField]
+	if !ok {
+		column = "modified_time"
+	}
+	direction := "DESC"
+	if req.SortType == "asc" {
+		direction = "ASC"
+	}
+
+	var rows []movies.Movie
+	if err := q.Preload("Categories").Preload("Countries").
+		Order(column + " " + direction).
+		Limit(req.Limit).
+		Offset((req.Page - 1) * req.Limit).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list movies: %w", err)
+	}
+
+	pagination, _ := utils.Paginate(total, req.Page, req.Limit)
+	items := make([]lib.MovieItem, 0, len(rows))
+	for _, m := range rows {
+		items = append(items, movieItemFromModel(m))
+	}
+
+	return &lib.MovieListResponse{
+		Items:      items,
+		Pagination: pagination,
+		Meta:       utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}, nil
+}
+
+// movieItemFromModel converts a stored movie to a list item
+func movieItemFromModel(m movies.Movie) lib.MovieItem {
+	return lib.MovieItem{
+		ID:             m.ID,
+		Name:           m.Name,
+		Slug:           m.Slug,
+		OriginName:     m.OriginName,
+		Type:           m.Type,
+		PosterURL:      m.PosterURL,
+		ThumbURL:       m.ThumbURL,
+		SubDocQuyen:    m.SubDocQuyen,
+		ChieuRap:       m.ChieuRap,
+		Time:           m.Time,
+		EpisodeCurrent: m.EpisodeCurrent,
+		Quality:        m.Quality,
+		Lang:           m.Lang,
+		Year:           m.Year,
+		Categories:     m.Categories,
+		Countries:      m.Countries,
+		TMDB:           m.TMDB,
+		IMDB:           m.IMDB,
+		Modified:       m.Modified,
+	}
+}
@@ -0,0 +1,363 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var trendingLog = logger.Named("trending")
+
+// Hits are counted per movie in hourly sorted sets (trending:{rank}:hits:<hour>).
+// ComputeTrending folds the buckets of a window into trending:{rank}:score:<window> with an
+// exponential decay, then splits the top of each ranking by category and country. The
+// all-time ranking is the undecayed running total. The rankings are rebuilt every
+// jobs.trending_interval. The {rank} and {pending_views} hash tags keep the keys folded or
+// renamed together in one slot on Redis Cluster.
+const (
+	trendingHitsPrefix   = "trending:{rank}:hits:"
+	trendingScorePrefix  = "trending:{rank}:score:"
+	trendingDimsKey      = "trending:{rank}:dimension_keys"
+	trendingPendingViews = "trending:{pending_views}"
+	trendingFlushing     = "trending:{pending_views}:flushing"
+
+	trendingBucketTTL = 8 * 24 * time.Hour // a week of buckets plus a margin
+	trendingDedupTTL  = 30 * time.Minute   // one hit per client and movie (or episode) per 30 minutes
+	trendingDepth     = 1000               // movies of each ranking split by category/country
+
+	viewWeight = 1.0
+	playWeight = 3.0 // starting an episode says more than opening the page
+)
+
+// trendingWindow is a decayed ranking over the last hours buckets
+type trendingWindow struct {
+	name     string
+	hours    int
+	halfLife time.Duration
+}
+
+var trendingWindows = []trendingWindow{
+	{name: "day", hours: 24, halfLife: 6 * time.Hour},
+	{name: "week", hours: 7 * 24, halfLife: 48 * time.Hour},
+}
+
+func trendingHitsKey(hour int64) string {
+	return trendingHitsPrefix + strconv.FormatInt(hour, 10)
+}
+
+// trendingScoreKey returns the ranking of window, optionally restricted to a category or country
+func trendingScoreKey(window, category, country string) string {
+	switch {
+	case category != "":
+		return trendingScorePrefix + window + ":category:" + category
+	case country != "":
+		return trendingScorePrefix + window + ":country:" + country
+	}
+	return trendingScorePrefix + window
+}
+
+// RecordView counts a detail page view of slug by client
+func RecordView(ctx context.Context, slug, client string) {
+	recordHit(ctx, slug, "trending:seen:view:"+slug+":"+client, viewWeight, true)
+}
+
+// RecordPlay counts an episode play of slug by client and reports whether it was counted.
+// A non-zero userID also adds the movie to that user's watch history.
+func RecordPlay(ctx context.Context, slug, episode, client string, userID uint) (bool, error) {
+	if err := movieHiddenError(ctx, slug); err != nil {
+		return false, err
+	}
+	var count int64
+	if err := config.DB.WithContext(ctx).Model(&movies.Movie{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, &utils.ServiceError{
+			StatusCode: http.StatusNotFound,
+			Code:       utils.ErrCodeNotFound,
+			Message:    fmt.Sprintf("movie not found: %s", slug),
+		}
+	}
+	if userID != 0 {
+		RecordWatch(ctx, userID, slug)
+	}
+	return recordHit(ctx, slug, "trending:seen:play:"+slug+":"+episode+":"+client, playWeight, false), nil
+}
+
+// recordHit adds weight to the current bucket of slug unless dedupKey was seen recently
+func recordHit(ctx context.Context, slug, dedupKey string, weight float64, view bool) bool {
+	rdb := config.RDB
+
+	fresh, err := rdb.SetNX(ctx, dedupKey, 1, trendingDedupTTL).Result()
+	if err != nil || !fresh {
+		return false
+	}
+
+	bucket := trendingHitsKey(time.Now().Unix() / 3600)
+	pipe := rdb.Pipeline()
+	pipe.ZIncrBy(ctx, bucket, weight, slug)
+	pipe.Expire(ctx, bucket, trendingBucketTTL)
+	pipe.ZIncrBy(ctx, trendingScoreKey("all", "", ""), weight, slug)
+	if view {
+		pipe.HIncrBy(ctx, trendingPendingViews, slug, 1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		trendingLog.Error(ctx, "failed to record hit", "slug", slug, "error", err)
+		return false
+	}
+	return true
+}
+
+// ComputeTrending rebuilds every ranking from the hourly buckets and drops the cached trending lists
+func ComputeTrending() error {
+	rdb := config.RDB
+	ctx := config.Ctx
+	hour := time.Now().Unix() / 3600
+
+	// 1. Decayed windows: bucket i hours old weighs 0.5^(i / half-life)
+	for _, w := range trendingWindows {
+		keys := make([]string, 0, w.hours)
+		weights := make([]float64, 0, w.hours)
+		for i := 0; i < w.hours; i++ {
+			keys = append(keys, trendingHitsKey(hour-int64(i)))
+			weights = append(weights, math.Pow(0.5, float64(i)/w.halfLife.Hours()))
+		}
+
+		scoreKey := trendingScoreKey(w.name, "", "")
+		tmpKey := scoreKey + ":tmp"
+		n, err := rdb.ZUnionStore(ctx, tmpKey, &redis.ZStore{Keys: keys, Weights: weights, Aggregate: "SUM"}).Result()
+		if err != nil {
+			return fmt.Errorf("failed to compute %s ranking: %w", w.name, err)
+		}
+		if n == 0 {
+			rdb.Del(ctx, scoreKey)
+			continue
+		}
+		if err := rdb.Rename(ctx, tmpKey, scoreKey).Err(); err != nil {
+			return fmt.Errorf("failed to publish %s ranking: %w", w.name, err)
+		}
+	}
+
+	// 2. Split each ranking by category and country
+	if err := splitTrendingByTaxonomy(); err != nil {
+		return err
+	}
+
+	// 3. Cached trending lists are stale now
+	_, err := FlushTag("trending")
+	return err
+}
+
+// splitTrendingByTaxonomy writes trending:{rank}:score:<window>:category|country:<slug> for the top movies
+func splitTrendingByTaxonomy() error {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	written := map[string][]redis.Z{}
+	for _, window := range lib.TrendingWindows {
+		top, err := rdb.ZRevRangeWithScores(ctx, trendingScoreKey(window, "", ""), 0, trendingDepth-1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read %s ranking: %w", window, err)
+		}
+		if len(top) == 0 {
+			continue
+		}
+		slugs := make([]string, 0, len(top))
+		for _, z := range top {
+			slugs = append(slugs, z.Member.(string))
+		}
+
+		for _, rel := range []struct {
+			t   taxonomy
+			dim string
+		}{
+			{categoryTaxonomy, "category"},
+			{countryTaxonomy, "country"},
+		} {
+			links, err := rel.t.slugsOfMovies(config.DB, slugs)
+			if err != nil {
+				return err
+			}
+			for _, z := range top {
+				for _, slug := range links[z.Member.(string)] {
+					key := trendingScorePrefix + window + ":" + rel.dim + ":" + slug
+					written[key] = append(written[key], z)
+				}
+			}
+		}
+	}
+
+	previous, err := rdb.SMembers(ctx, trendingDimsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	for _, key := range previous {
+		if _, ok := written[key]; !ok {
+			pipe.Del(ctx, key)
+		}
+	}
+	pipe.Del(ctx, trendingDimsKey)
+	for key, members := range written {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.SAdd(ctx, trendingDimsKey, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to write trending by taxonomy: %w", err)
+	}
+	return nil
+}
+
+// GetTrending returns a page of a trending ranking
+func GetTrending(ctx context.Context, req lib.TrendingRequest) (*lib.TrendingResponse, error) {
+	if err := categoryHiddenError(ctx, req.Category); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("trending:list:%s:%s:%s:%d:%d", req.Window, req.Category, req.Country, req.Page, req.Limit)
+
+	// 1. Redis cache
+	var cached lib.TrendingResponse
+	if getCached(ctx, cacheKey, &cached) && cached.Items != nil {
+		cached.Meta.FromCache = true
+		return applyTrendingOverrides(ctx, &cached), nil
+	}
+
+	// 2. Ranking page
+	rdb := config.RDB
+	scoreKey := trendingScoreKey(req.Window, req.Category, req.Country)
+
+	total, err := rdb.ZCard(ctx, scoreKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trending ranking: %w", err)
+	}
+	start := int64((req.Page - 1) * req.Limit)
+	ranked, err := rdb.ZRevRangeWithScores(ctx, scoreKey, start, start+int64(req.Limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trending ranking: %w", err)
+	}
+
+	// 3. Movies from the DB (a hit is only recorded for a movie that was served)
+	slugs := make([]string, 0, len(ranked))
+	for _, z := range ranked {
+		slugs = append(slugs, z.Member.(string))
+	}
+	bySlug, err := storedMovies(ctx, slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]lib.TrendingItem, 0, len(ranked))
+	for _, z := range ranked {
+		m, ok := bySlug[z.Member.(string)]
+		if !ok {
+			continue
+		}
+		items = append(items, lib.TrendingItem{MovieItem: movieItemFromModel(m), Score: z.Score})
+	}
+
+	pagination, _ := utils.Paginate(total, req.Page, req.Limit)
+	result := &lib.TrendingResponse{
+		Window:     req.Window,
+		Items:      items,
+		Pagination: pagination,
+		Meta:       utils.Meta{FromCache: false, Timestamp: time.Now().Unix()},
+	}
+
+	// 4. Cache until the next ComputeTrending
+	setTagged(cacheKey, "trending", result)
+
+	return applyTrendingOverrides(ctx, result), nil
+}
+
+// storedMovies loads the mirrored movies of slugs, keyed by slug
+func storedMovies(ctx context.Context, slugs []string) (map[string]movies.Movie, error) {
+	bySlug := make(map[string]movies.Movie, len(slugs))
+	if len(slugs) == 0 {
+		return bySlug, nil
+	}
+	var rows []movies.Movie
+	if err := config.DB.WithContext(ctx).Preload("Categories").Preload("Countries").
+		Where("slug IN ?", slugs).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load movies: %w", err)
+	}
+	for _, m := range rows {
+		bySlug[m.Slug] = m
+	}
+	return bySlug, nil
+}
+
+func applyTrendingOverrides(ctx context.Context, res *lib.TrendingResponse) *lib.TrendingResponse {
+	o := currentOverrides(ctx)
+	out := *res
+	out.Items = make([]lib.TrendingItem, 0, len(res.Items))
+	for _, it := range res.Items {
+		kept := o.items([]lib.MovieItem{it.MovieItem})
+		if len(kept) == 0 {
+			continue
+		}
+		it.MovieItem = kept[0]
+		out.Items = append(out.Items, it)
+	}
+	return &out
+}
+
+// PersistViews adds the views counted since the last run to movies.view.
+// The pending counts are moved aside first so views recorded meanwhile wait for the next run;
+// a batch whose update failed is retried before a new one is taken.
+func PersistViews() (int, error) {
+	rdb := config.RDB
+	ctx := config.Ctx
+
+	// 1. Take the pending batch (or the one left by a failed run)
+	if n, err := rdb.Exists(ctx, trendingFlushing).Result(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		pending, err := rdb.Exists(ctx, trendingPendingViews).Result()
+		if err != nil || pending == 0 {
+			return 0, err
+		}
+		if err := rdb.Rename(ctx, trendingPendingViews, trendingFlushing).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	counts, err := rdb.HGetAll(ctx, trendingFlushing).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// 2. One transaction so a batch is applied once or not at all
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for slug, raw := range counts {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 {
+				continue
+			}
+			if err := tx.Model(&movies.Movie{}).Where("slug = ?", slug).
+				UpdateColumn("view", gorm.Expr("view + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to persist views: %w", err)
+	}
+
+	// 3. Done with this batch
+	return len(counts), rdb.Del(ctx, trendingFlushing).Err()
+}
@@ -0,0 +1,150 @@
+package movies
+
+import (
+	"ani4s/src/config"
+	lib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// upstreamPagination is the pagination block used by phimapi
+type upstreamPagination struct {
+	TotalItems        int64 `json:"totalItems"`
+	TotalItemsPerPage int   `json:"totalItemsPerPage"`
+	CurrentPage       int   `json:"currentPage"`
+	TotalPages        int   `json:"totalPages"`
+}
+
+// upstreamListResponse covers both list shapes of phimapi:
+// items at the root (phim-moi-cap-nhat) and data.items (v1/api/*)
+type upstreamListResponse struct {
+	Items      []lib.MovieItem     `json:"items"`
+	Pagination *upstreamPagination `json:"pagination"`
+	Data       *struct {
+		Items  []lib.MovieItem `json:"items"`
+		Params struct {
+			Pagination upstreamPagination `json:"pagination"`
+		} `json:"params"`
+	} `json:"data"`
+}
+
+// decodeUpstreamList parses a phimapi list body into typed items and pagination
+func decodeUpstreamList(ctx context.Context, body []byte) ([]lib.MovieItem, utils.Pagination, error) {
+	var raw upstreamListResponse
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, utils.Pagination{}, &utils.ServiceError{
+			StatusCode: http.StatusBadGateway,
+			Code:       utils.ErrCodeUpstream,
+			Message:    fmt.Sprintf("failed to parse API response: %v", err),
+		}
+	}
+
+	var items []lib.MovieItem
+	var p upstreamPagination
+	switch {
+	case raw.Data != nil && raw.Data.Items != nil:
+		items = raw.Data.Items
+		p = raw.Data.Params.Pagination
+	case raw.Items != nil:
+		items = raw.Items
+		if raw.Pagination != nil {
+			p = *raw.Pagination
+		}
+	default:
+		return nil, utils.Pagination{}, &utils.ServiceError{
+			StatusCode: http.StatusBadGateway,
+			Code:       utils.ErrCodeUpstream,
+			Message:    "unexpected response structure",
+		}
+	}
+
+	pagination, _ := utils.Paginate(p.TotalItems, p.CurrentPage, p.TotalItemsPerPage)
+	return enrichThumbFromDatabase(ctx, items), pagination, nil
+}
+
+// upstreamEndpoint reduces a phimapi URL to its route template (slugs replaced by
+// placeholders, query dropped) so it can label metrics without unbounded cardinality
+func upstreamEndpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid"
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "phim":
+		return "/phim/:slug"
+	case len(parts) == 4 && parts[0] == "v1" && parts[1] == "api":
+		return "/v1/api/" + parts[2] + "/:slug"
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// FetchListPage fetches one phimapi list page without touching the cache
+func FetchListPage(ctx context.Context, targetURL string) ([]lib.MovieItem, utils.Pagination, error) {
+	responseBody, err := MakeAnonymousRequest(ctx, targetURL)
+	if err != nil {
+		return nil, utils.Pagination{}, upstreamError("movie list page", err)
+	}
+	return decodeUpstreamList(ctx, responseBody)
+}
+
+// enrichThumbFromDatabase replaces thumb_url with the locally mirrored path when the movie is stored
+func enrichThumbFromDatabase(ctx context.Context, items []lib.MovieItem) []lib.MovieItem {
+	db := config.DB.WithContext(ctx)
+
+	// 1. Collect all valid IDs
+	var ids []string
+	for _, item := range items {
+		if item.ID != "" {
+			ids = append(ids, item.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return items
+	}
+
+	// 2. Query từ DB (Movie.ID -> ThumbURL)
+	type Result struct {
+		ID       string
+		ThumbURL string
+	}
+	var dbResults []Result
+	if err := db.
+		Model(&movies.Movie{}).
+		Select("id", "thumb_url").
+		Where("id IN ?", ids).
+		Find(&dbResults).Error; err != nil {
+		moviesLog.Error(config.Ctx, "failed to load stored thumbnails", "error", err)
+		return items
+	}
+
+	thumbMap := make(map[string]string, len(dbResults))
+	for _, r := range dbResults {
+		if r.ThumbURL != "" {
+			thumbMap[r.ID] = r.ThumbURL
+		}
+	}
+
+	// 3. Cập nhật nếu có DB match
+	for i := range items {
+		if newThumb, exists := thumbMap[items[i].ID]; exists {
+			items[i].ThumbURL = newThumb
+		}
+	}
+	return items
+}
+
+// upstreamError wraps a failed phimapi call as a 502 service error
+func upstreamError(what string, err error) error {
+	return &utils.ServiceError{
+		StatusCode: http.StatusBadGateway,
+		Code:       utils.ErrCodeUpstream,
+		Message:    fmt.Sprintf("failed to fetch %s: %v", what, err),
+	}
+}
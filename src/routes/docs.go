@@ -0,0 +1,303 @@
+package routes
+
+import (
+	"ani4s/src/cache"
+	"ani4s/src/health"
+	adminlib "ani4s/src/modules/admin/lib"
+	docs "ani4s/src/modules/docs/services"
+	graphql "ani4s/src/modules/graphql/controllers"
+	movieslib "ani4s/src/modules/movies/lib"
+	moviesmodels "ani4s/src/modules/movies/models"
+	movies "ani4s/src/modules/movies/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// operations documents every route registered by the app, keyed by "METHOD /full/path".
+// RegisterRoutes logs any route missing here so the OpenAPI document cannot drift silently.
+var operations = map[string]docs.Operation{
+	"GET /ws": {
+		Summary: "WebSocket connection (upgrade), requires ?userId=",
+		Tags:    []string{"socket"},
+	},
+	"GET /healthz": {
+		Summary: "Liveness probe",
+		Tags:    []string{"health"},
+		Raw:     gin.H{},
+	},
+	"GET /readyz": {
+		Summary: "Readiness probe with the status and latency of every dependency; 503 only when down",
+		Tags:    []string{"health"},
+		Raw:     health.Report{},
+	},
+	"GET /metrics": {
+		Summary:     "Prometheus metrics",
+		Tags:        []string{"health"},
+		Raw:         "",
+		ContentType: "text/plain",
+	},
+	"GET /openapi.json": {
+		Summary: "This OpenAPI document",
+		Tags:    []string{"docs"},
+		Raw:     map[string]interface{}{},
+	},
+	"GET /docs/*any": {
+		Summary:     "Swagger UI",
+		Tags:        []string{"docs"},
+		Raw:         "",
+		ContentType: "text/html",
+	},
+	"POST /graphql": {
+		Summary: "GraphQL endpoint over the movie catalog",
+		Tags:    []string{"graphql"},
+		Body:    graphql.GraphQLRequest{},
+		Raw:     map[string]interface{}{},
+	},
+	"GET /graphql": {
+		Summary: "GraphQL endpoint (query string form)",
+		Tags:    []string{"graphql"},
+		Query:   graphql.GraphQLRequest{},
+		Raw:     map[string]interface{}{},
+	},
+	"GET /api/v1/hello": {
+		Summary: "Hello world",
+		Tags:    []string{"misc"},
+		Data:    map[string]string{},
+	},
+	"POST /api/v1/phim/moi-cap-nhat": {
+		Summary:   "Newest updated movies",
+		Tags:      []string{"phim"},
+		Body:      movieslib.NewestMoviesRequest{},
+		Data:      []movieslib.MovieItem{},
+		Paginated: true,
+	},
+	"GET /api/v1/phim/:slug": {
+		Summary: "Movie details with episodes",
+		Tags:    []string{"phim"},
+		Data:    movieslib.MovieDetail{},
+	},
+	"POST /api/v1/phim/danh-sach": {
+		Summary:   "Movie list by type",
+		Tags:      []string{"phim"},
+		Body:      movieslib.MovieListRequest{},
+		Data:      []movieslib.MovieItem{},
+		Paginated: true,
+	},
+	"POST /api/v1/phim/tim-kiem": {
+		Summary:   "Search movies",
+		Tags:      []string{"phim"},
+		Body:      movieslib.MovieSearchRequest{},
+		Data:      []movieslib.MovieItem{},
+		Paginated: true,
+	},
+	"POST /api/v1/phim/the-loai": {
+		Summary:   "Movies by category",
+		Tags:      []string{"phim"},
+		Body:      movieslib.MoviesByCategoryRequest{},
+		Data:      []movieslib.MovieItem{},
+		Paginated: true,
+	},
+	"POST /api/v1/phim/quoc-gia": {
+		Summary:   "Movies by country",
+		Tags:      []string{"phim"},
+		Body:      movieslib.MoviesByCategoryRequest{},
+		Data:      []movieslib.MovieItem{},
+		Paginated: true,
+	},
+	"GET /api/v1/phim/danh-sach": {
+		Summary:     "Movie list by type (cacheable GET variant)",
+		Description: "Responses carry Cache-Control and an ETag; send If-None-Match to get 304.",
+		Tags:        []string{"phim"},
+		Query:       movieslib.MovieListRequest{},
+		Data:        []movieslib.MovieItem{},
+		Paginated:   true,
+	},
+	"GET /api/v1/phim/tim-kiem": {
+		Summary:     "Search movies (cacheable GET variant)",
+		Description: "Responses carry Cache-Control and an ETag; send If-None-Match to get 304.",
+		Tags:        []string{"phim"},
+		Query:       movieslib.MovieSearchRequest{},
+		Data:        []movieslib.MovieItem{},
+		Paginated:   true,
+	},
+	"GET /api/v1/phim/the-loai": {
+		Summary:     "Movies by category (cacheable GET variant)",
+		Description: "Responses carry Cache-Control and an ETag; send If-None-Match to get 304.",
+		Tags:        []string{"phim"},
+		Query:       movieslib.MoviesByCategoryRequest{},
+		Data:        []movieslib.MovieItem{},
+		Paginated:   true,
+	},
+	"GET /api/v1/phim/quoc-gia": {
+		Summary:     "Movies by country (cacheable GET variant)",
+		Description: "Responses carry Cache-Control and an ETag; send If-None-Match to get 304.",
+		Tags:        []string{"phim"},
+		Query:       movieslib.MoviesByCategoryRequest{},
+		Data:        []movieslib.MovieItem{},
+		Paginated:   true,
+	},
+	"GET /api/v1/phim/categories": {
+		Summary: "All categories",
+		Tags:    []string{"phim"},
+		Data:    []moviesmodels.Category{},
+	},
+	"GET /api/v1/phim/country": {
+		Summary: "All countries",
+		Tags:    []string{"phim"},
+		Data:    []moviesmodels.Country{},
+	},
+	"GET /api/v1/phim/trending": {
+		Summary:   "Trending movies (window=day|week|all), optionally by category or country",
+		Tags:      []string{"phim"},
+		Query:     movieslib.TrendingRequest{},
+		Data:      []movieslib.TrendingItem{},
+		Paginated: true,
+	},
+	"POST /api/v1/phim/:slug/play": {
+		Summary:     "Record an episode play for the trending rankings",
+		Description: "With a user token the movie also joins that user's watch history.",
+		Tags:        []string{"phim"},
+		User:        docs.OptionalUserToken,
+		Body:        movieslib.PlayRequest{},
+		Data:        movieslib.PlayResponse{},
+	},
+	"GET /api/v1/phim/:slug/similar": {
+		Summary: "Similar titles (shared metadata and co-watch)",
+		Tags:    []string{"phim"},
+		Query:   movieslib.RecommendationRequest{},
+		Data:    []movieslib.MovieItem{},
+	},
+	"GET /api/v1/me/recommendations": {
+		Summary: "\"Because you watched\" rows from the watch history of the token's user",
+		Tags:    []string{"recommendations"},
+		User:    docs.RequiredUserToken,
+		Query:   movieslib.RecommendationRequest{},
+		Data:    []movieslib.RecommendationSection{},
+	},
+	"GET /api/v1/home": {
+		Summary: "Homepage sections built from the active collections",
+		Tags:    []string{"home"},
+		Data:    []movieslib.HomeSection{},
+	},
+	"GET /api/v1/static/*filepath": {
+		Summary:     "Image proxy backed by MinIO",
+		Tags:        []string{"static"},
+		Raw:         []byte{},
+		ContentType: "application/octet-stream",
+	},
+	"GET /api/v1/admin/cache/stats": {
+		Summary: "L1 (in-process) and L2 (Redis) cache hit rates of the replica that answers",
+		Tags:    []string{"admin"},
+		Data:    cache.Stats{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/cache/tags": {
+		Summary: "Cache tags with key counts and memory usage",
+		Tags:    []string{"admin"},
+		Data:    []movies.TagStat{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/cache/tags/:tag": {
+		Summary: "Keys indexed by a cache tag",
+		Tags:    []string{"admin"},
+		Data:    adminlib.CacheTagKeysResponse{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/cache/tags/:tag": {
+		Summary: "Purge every payload of a cache tag",
+		Tags:    []string{"admin"},
+		Data:    adminlib.CachePurgeResponse{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/cache/keys": {
+		Summary: "Inspect one cached payload",
+		Tags:    []string{"admin"},
+		Query:   adminlib.CacheKeyRequest{},
+		Data:    movies.CachedEntry{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/cache/keys": {
+		Summary: "Purge every key starting with a prefix",
+		Tags:    []string{"admin"},
+		Query:   adminlib.CachePrefixRequest{},
+		Data:    adminlib.CachePurgeResponse{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/cache/movies/:slug": {
+		Summary: "Purge the details of a movie and every cached list containing it",
+		Tags:    []string{"admin"},
+		Data:    adminlib.CachePurgeResponse{},
+		Admin:   true,
+	},
+	"POST /api/v1/admin/cache/refresh": {
+		Summary: "Refresh a movie (synchronous) or a whole cache tag (background, 202)",
+		Tags:    []string{"admin"},
+		Body:    adminlib.CacheRefreshRequest{},
+		Data:    adminlib.CacheRefreshResponse{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/overrides": {
+		Summary: "Category and movie overrides",
+		Tags:    []string{"admin"},
+		Data:    adminlib.OverridesResponse{},
+		Admin:   true,
+	},
+	"PUT /api/v1/admin/overrides/categories/:slug": {
+		Summary: "Rename or hide a category",
+		Tags:    []string{"admin"},
+		Body:    adminlib.CategoryOverrideRequest{},
+		Data:    moviesmodels.CategoryOverride{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/overrides/categories/:slug": {
+		Summary: "Remove a category override",
+		Tags:    []string{"admin"},
+		Data:    map[string]string{},
+		Admin:   true,
+	},
+	"PUT /api/v1/admin/overrides/movies/:slug": {
+		Summary: "Override the title, poster or description of a movie, or hide it",
+		Tags:    []string{"admin"},
+		Body:    adminlib.MovieOverrideRequest{},
+		Data:    moviesmodels.MovieOverride{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/overrides/movies/:slug": {
+		Summary: "Remove a movie override",
+		Tags:    []string{"admin"},
+		Data:    map[string]string{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/collections": {
+		Summary: "Collections in homepage order, scheduled or not",
+		Tags:    []string{"admin"},
+		Data:    []moviesmodels.Collection{},
+		Admin:   true,
+	},
+	"POST /api/v1/admin/collections/order": {
+		Summary: "Reorder homepage collections",
+		Tags:    []string{"admin"},
+		Body:    adminlib.CollectionOrderRequest{},
+		Data:    []moviesmodels.Collection{},
+		Admin:   true,
+	},
+	"GET /api/v1/admin/collections/:slug/preview": {
+		Summary: "Render a collection now, ignoring its schedule",
+		Tags:    []string{"admin"},
+		Data:    movieslib.HomeSection{},
+		Admin:   true,
+	},
+	"PUT /api/v1/admin/collections/:slug": {
+		Summary: "Create or replace a collection (manual slug list or saved query)",
+		Tags:    []string{"admin"},
+		Body:    adminlib.CollectionRequest{},
+		Data:    moviesmodels.Collection{},
+		Admin:   true,
+	},
+	"DELETE /api/v1/admin/collections/:slug": {
+		Summary: "Remove a collection",
+		Tags:    []string{"admin"},
+		Data:    map[string]string{},
+		Admin:   true,
+	},
+}
@@ -1,9 +1,18 @@
 package routes
 
 import (
-	"ani4s/src/config"
+	"ani4s/src/health"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
+	"ani4s/src/middlewares"
+	admin "ani4s/src/modules/admin/controllers"
+	docs "ani4s/src/modules/docs/controllers"
+	docsService "ani4s/src/modules/docs/services"
 	files "ani4s/src/modules/files/controllers"
+	graphql "ani4s/src/modules/graphql/controllers"
 	movies "ani4s/src/modules/movies/controllers"
+	"ani4s/src/utils"
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,25 +23,20 @@ func RegisterRoutes(router *gin.Engine) {
 
 	// Hello World
 	api.GET("hello", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": gin.H{
-				"code":    http.StatusOK,
-				"message": "Hello world",
-			},
-		})
+		utils.RespondSuccess(c, gin.H{"message": "Hello world"}, nil, nil)
 	})
 
 	router.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
-	// Update when db available
+	// Prometheus scrape endpoint
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Ready unless Postgres is down; Redis, MinIO or phimapi being down only degrades the service
 	router.GET("/readyz", func(c *gin.Context) {
-		if config.CheckConnection() {
-			c.JSON(http.StatusOK, gin.H{"status": "ready"})
-		} else {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable"})
-		}
+		report := health.Check(c.Request.Context())
+		c.JSON(report.HTTPStatus(), report)
 	})
 
 	// Newest Movies Routes
@@ -40,18 +44,68 @@ func RegisterRoutes(router *gin.Engine) {
 	{
 		moviesRoutes.POST("moi-cap-nhat", movies.NewestUpdateMovies)
 		moviesRoutes.GET(":slug", movies.GetMovieDetails)
+		moviesRoutes.POST(":slug/play", middlewares.UserIdentity(), movies.PlayEpisode)
+		moviesRoutes.GET(":slug/similar", movies.GetSimilarMovies)
+		moviesRoutes.GET("trending", movies.GetTrending)
 		moviesRoutes.POST("danh-sach", movies.GetMovieList)
 		moviesRoutes.POST("tim-kiem", movies.SearchMovies)
 		moviesRoutes.POST("the-loai", movies.ListMoviesByCategory)
 		moviesRoutes.POST("quoc-gia", movies.ListMoviesByCountry)
+		moviesRoutes.GET("danh-sach", movies.GetMovieListQuery)
+		moviesRoutes.GET("tim-kiem", movies.SearchMoviesQuery)
+		moviesRoutes.GET("the-loai", movies.ListMoviesByCategoryQuery)
+		moviesRoutes.GET("quoc-gia", movies.ListMoviesByCountryQuery)
 		moviesRoutes.GET("categories", movies.ListCategories)
 		moviesRoutes.GET("country", movies.ListCountry)
 	}
 
+	// Homepage sections built from the admin collections
+	api.GET("home", movies.GetHome)
+
+	// "Because you watched" rows from the caller's own history recorded by /phim/:slug/play
+	api.GET("me/recommendations", middlewares.RequireUser(), movies.GetUserRecommendations)
+
 	// Static Proxy MinIO
 	staticProxyRoutes := api.Group("/static")
 	{
 		staticProxyRoutes.GET("/*filepath", files.FileController)
-		
+	}
+
+	// Admin: cache management, overrides and collections
+	adminRoutes := api.Group("/admin", middlewares.AdminAuth())
+	{
+		adminRoutes.GET("cache/stats", admin.GetCacheStats)
+		adminRoutes.GET("cache/tags", admin.ListCacheTags)
+		adminRoutes.GET("cache/tags/:tag", admin.ListCacheTagKeys)
+		adminRoutes.DELETE("cache/tags/:tag", admin.PurgeCacheTag)
+		adminRoutes.GET("cache/keys", admin.InspectCacheKey)
+		adminRoutes.DELETE("cache/keys", admin.PurgeCachePrefix)
+		adminRoutes.DELETE("cache/movies/:slug", admin.PurgeCacheMovie)
+		adminRoutes.POST("cache/refresh", admin.RefreshCache)
+
+		adminRoutes.GET("overrides", admin.ListOverrides)
+		adminRoutes.PUT("overrides/categories/:slug", admin.SaveCategoryOverride)
+		adminRoutes.DELETE("overrides/categories/:slug", admin.DeleteCategoryOverride)
+		adminRoutes.PUT("overrides/movies/:slug", admin.SaveMovieOverride)
+		adminRoutes.DELETE("overrides/movies/:slug", admin.DeleteMovieOverride)
+
+		adminRoutes.GET("collections", admin.ListCollections)
+		adminRoutes.POST("collections/order", admin.ReorderCollections)
+		adminRoutes.GET("collections/:slug/preview", admin.PreviewCollection)
+		adminRoutes.PUT("collections/:slug", admin.SaveCollection)
+		adminRoutes.DELETE("collections/:slug", admin.DeleteCollection)
+	}
+
+	// GraphQL
+	router.POST("/graphql", graphql.GraphQLHandler)
+	router.GET("/graphql", graphql.GraphQLHandler)
+
+	// API documentation
+	router.GET("/openapi.json", docs.OpenAPISpec(router, operations))
+	router.GET("/docs/*any", docs.SwaggerUI)
+
+	// Every registered route must be described in operations
+	for _, problem := range docsService.Validate(router.Routes(), operations) {
+		logger.Named("openapi").Warn(context.Background(), problem)
 	}
 }
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	docsService "ani4s/src/modules/docs/services"
+	"ani4s/src/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter builds the engine the way main does
+func newRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", services.WebSocketHandler)
+	RegisterRoutes(router)
+	return router
+}
+
+func TestOperationsMatchRegisteredRoutes(t *testing.T) {
+	router := newRouter()
+	for _, problem := range docsService.Validate(router.Routes(), operations) {
+		t.Error(problem)
+	}
+}
+
+var ginParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+func TestOpenAPIListsEveryRoute(t *testing.T) {
+	router := newRouter()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json returned %d", rec.Code)
+	}
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	for _, route := range router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		if _, ok := doc.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is missing from /openapi.json (as %s)", route.Method, route.Path, path)
+		}
+	}
+}
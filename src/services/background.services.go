@@ -1,24 +1,103 @@
 package services
 
 import (
+	"ani4s/src/cache"
 	"ani4s/src/config"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
 	file "ani4s/src/modules/files/services"
+	movieslib "ani4s/src/modules/movies/lib"
 	movies2 "ani4s/src/modules/movies/models"
 	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/tracing"
 	"ani4s/src/utils"
 	"net/url"
 
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// SetupBackgroundJobs sets up and starts background jobs like syncing movie details from cached keys.
+// jobsCtx is the parent of scheduled runs: StopBackgroundJobs cancels it when runs
+// outlive the drain timeout. running counts every run, scheduled or triggered.
+var (
+	scheduler           *cron.Cron
+	jobsCtx, cancelJobs = context.WithCancel(context.Background())
+	running             sync.WaitGroup
+)
+
+var (
+	jobLog   = logger.Named("jobs")
+	syncLog  = logger.Named("sync")
+	imageLog = logger.Named("imagesync")
+)
+
+// RunJob runs fn as one job run. Its context logger carries the job name and a fresh
+// run_id, the start and end of the run are logged with its duration, and a panic is
+// logged and returned as an error instead of crashing the process. The run is a span of
+// its own, a child of the request span when an admin request triggered it.
+func RunJob(ctx context.Context, job string, fn func(ctx context.Context) error) (err error) {
+	running.Add(1)
+	defer running.Done()
+
+	parentTraceID, _ := tracing.IDs(ctx)
+	ctx, span := tracing.Start(ctx, "job "+job, attribute.String("job", job))
+	ctx = logger.With(ctx, "job", job, "run_id", logger.NewID())
+	// A run triggered by a request already logs the trace id of that request
+	if traceID, _ := tracing.IDs(ctx); traceID != "" && traceID != parentTraceID {
+		ctx = logger.With(ctx, "trace_id", traceID)
+	}
+	start := time.Now()
+	jobLog.Info(ctx, "job started")
+
+	defer func() {
+		outcome := "success"
+		if r := recover(); r != nil {
+			outcome = "panic"
+			err = fmt.Errorf("panic: %v", r)
+			jobLog.Error(ctx, "job panicked", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		} else if err != nil {
+			outcome = "failure"
+		}
+		tracing.End(span, err)
+		elapsed := time.Since(start)
+		metrics.JobDuration.WithLabelValues(job, outcome).Observe(elapsed.Seconds())
+		if err != nil {
+			jobLog.Error(ctx, "job failed", "duration_ms", elapsed.Milliseconds(), "error", err)
+			return
+		}
+		jobLog.Info(ctx, "job finished", "duration_ms", elapsed.Milliseconds())
+	}()
+
+	return fn(ctx)
+}
+
+// schedule runs fn as a job, on a goroutine of its own, every time spec fires
+func schedule(c *cron.Cron, spec, job string, fn func(ctx context.Context) error) {
+	if _, err := c.AddFunc(spec, func() {
+		_ = RunJob(jobsCtx, job, fn)
+	}); err != nil {
+		jobLog.Error(config.Ctx, "invalid schedule", "job", job, "spec", spec, "error", err)
+	}
+}
+
+// every is the cron spec of a job run at a fixed interval
+func every(d time.Duration) string {
+	return fmt.Sprintf("@every %s", d)
+}
+
+// SetupBackgroundJobs sets up and starts background jobs like syncing movie details from
+// cached keys, on the schedules of the jobs settings.
 func SetupBackgroundJobs() {
 	c := cron.New()
+	jobs := config.App.Jobs
 
 	tagKeys := []string{
 		"movie_list:cached_keys",
@@ -31,93 +110,139 @@ func SetupBackgroundJobs() {
 
 	for _, tagKey := range tagKeys {
 		tk := tagKey // tránh capture biến loop
-		c.AddFunc("@every 15m", func() {
-			go syncMoviesFromCacheByTagKey(tk)
+		schedule(c, every(jobs.SyncInterval), "sync:"+strings.TrimSuffix(tk, ":cached_keys"), func(ctx context.Context) error {
+			return syncMoviesFromCacheByTagKey(ctx, tk)
 		})
 	}
-	c.AddFunc("@every 10m", func() {
-		go FetchAndUpdateThumbnails()
+	schedule(c, every(jobs.ImageSyncInterval), "image_sync", FetchAndUpdateThumbnails)
+	schedule(c, every(jobs.TrendingInterval), "trending", refreshTrending)
+	schedule(c, every(jobs.RecommendInterval), "recommend", rebuildRecommendations)
+	schedule(c, jobs.CrawlSchedule, "crawl", func(ctx context.Context) error {
+		return RunCatalogCrawl(ctx, DefaultCrawlOptions())
 	})
 
 	c.Start()
-	log.Println("[Cron] Background jobs initialized with tag keys:", tagKeys)
+	scheduler = c
+	jobLog.Info(config.Ctx, "background jobs initialized", "tag_keys", tagKeys)
+
+	// Pick up a catalog crawl interrupted by the last shutdown
+	go ResumeCatalogCrawl()
+}
+
+// StopBackgroundJobs stops scheduling runs and waits for the running ones. When ctx ends
+// first, the scheduled runs are cancelled (the crawl resumes from its checkpoint on the
+// next start) and ctx's error is returned.
+func StopBackgroundJobs(ctx context.Context) error {
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		jobLog.Info(ctx, "background jobs stopped")
+		return nil
+	case <-ctx.Done():
+		cancelJobs()
+		jobLog.Warn(ctx, "background jobs still running at shutdown, cancelled")
+		return ctx.Err()
+	}
+}
+
+// refreshTrending rebuilds the trending rankings and stores the views counted since the last run
+func refreshTrending(ctx context.Context) error {
+	computeErr := movies.ComputeTrending()
+	n, err := movies.PersistViews()
+	if err != nil {
+		return errors.Join(computeErr, fmt.Errorf("failed to persist views: %w", err))
+	}
+	jobLog.Info(ctx, "persisted views", "movies", n)
+	return computeErr
+}
+
+// rebuildRecommendations recomputes the similar titles of every movie
+func rebuildRecommendations(ctx context.Context) error {
+	n, err := movies.RebuildRecommendations()
+	if err != nil {
+		return err
+	}
+	jobLog.Info(ctx, "recommendations indexed", "movies", n)
+	return nil
 }
 
 // syncMoviesFromCacheByTagKey reads cached movie list keys from a specific tagKey and syncs details.
-func syncMoviesFromCacheByTagKey(tagKey string) {
-	rdb := config.RDB
-	ctx := config.Ctx
-	log.Printf("[Sync] Starting background movie detail sync from Redis tag key: %s", tagKey)
+func syncMoviesFromCacheByTagKey(ctx context.Context, tagKey string) error {
+	syncLog.Info(ctx, "starting movie detail sync", "tag_key", tagKey)
 
 	// Step 1: Get all cache keys in the given tag set
-	keys, err := rdb.SMembers(ctx, tagKey).Result()
-	if err != nil || len(keys) == 0 {
-		log.Printf("[Sync] No keys found or error occurred while reading Redis tag set %s: %v", tagKey, err)
-		return
+	keys, err := config.Cache.Members(ctx, tagKey)
+	if err != nil {
+		return fmt.Errorf("failed to read tag set %s: %w", tagKey, err)
+	}
+	if len(keys) == 0 {
+		syncLog.Info(ctx, "no cached keys to sync", "tag_key", tagKey)
+		return nil
 	}
+	synced, failed := 0, 0
 	for _, cacheKey := range keys {
-		cached, err := rdb.Get(ctx, cacheKey).Result()
+		cached, err := config.Cache.Get(ctx, cacheKey)
 		if err != nil {
-			log.Printf("[Sync] Failed to read cache key %s: %v", cacheKey, err)
+			syncLog.Debug(ctx, "failed to read cache key", "key", cacheKey, "error", err)
 			continue
 		}
 
-		var data map[string]interface{}
-		if err := json.Unmarshal([]byte(cached), &data); err != nil {
-			log.Printf("[Sync] Failed to unmarshal cached data for key %s: %v", cacheKey, err)
+		// List and search payloads share the typed `items` block
+		var data struct {
+			Items []movieslib.MovieItem `json:"items"`
+		}
+		if err := cache.Unmarshal(cached, &data); err != nil {
+			syncLog.Warn(ctx, "failed to unmarshal cached data", "key", cacheKey, "error", err)
 			continue
 		}
-
-		// Try to detect structure: ["data"]["items"] for list/search results
-		items, ok := extractItemsFromData(data)
-		if !ok {
-			log.Printf("[Sync] Could not find 'data.items' in cache key %s", cacheKey)
+		if len(data.Items) == 0 {
+			syncLog.Debug(ctx, "no items in cached payload", "key", cacheKey)
 			continue
 		}
 
-		for _, item := range items {
-			if movieMap, ok := item.(map[string]interface{}); ok {
-				slug, _ := movieMap["slug"].(string)
-				if slug != "" {
-					log.Printf("[Sync] Syncing details for slug: %s", slug)
-					res, err := movies.GetDetailsMovie(slug)
-					if err != nil {
-						log.Printf("[Sync] Error syncing slug %s: %v", slug, err)
-						continue
-					}
-
-					// Truy cập res["data"]["movie"]["thumb_url"]
-					dataMap, ok := res["data"].(map[string]interface{})
-					if !ok {
-						log.Printf("[Sync] Invalid data format for slug %s", slug)
-						continue
-					}
-
-					movieData, ok := dataMap["movie"].(map[string]interface{})
-					if !ok {
-						log.Printf("[Sync] Invalid movie format for slug %s", slug)
-						continue
-					}
-
-					thumbURL, _ := movieData["thumb_url"].(string)
-					if thumbURL == "" {
-						log.Printf("[Sync] Empty thumb_url for slug %s", slug)
-						continue
-					}
-
-					err = syncImage(thumbURL)
-					if err != nil {
-						log.Printf("[Sync] Error syncing image from thumbnail: %v", err)
-					}
-				}
+		for _, item := range data.Items {
+			slug := item.Slug
+			if slug == "" {
+				continue
+			}
+			syncLog.Debug(ctx, "syncing details", "slug", slug)
+			if err := movies.RefreshMovieIfModified(ctx, slug, item.Modified.Time); err != nil {
+				syncLog.Warn(ctx, "failed to refresh movie", "slug", slug, "error", err)
+			}
+			res, err := movies.GetDetailsMovie(ctx, slug)
+			if err != nil {
+				syncLog.Warn(ctx, "failed to sync movie", "slug", slug, "error", err)
+				failed++
+				continue
+			}
+			synced++
+
+			thumbURL := res.Movie.ThumbURL
+			if thumbURL == "" {
+				syncLog.Debug(ctx, "empty thumb_url", "slug", slug)
+				continue
+			}
+
+			err = syncImage(ctx, thumbURL)
+			if err != nil {
+				syncLog.Warn(ctx, "failed to sync thumbnail", "slug", slug, "error", err)
 			}
 		}
 	}
 
-	log.Printf("[Sync] Finished sync for tagKey: %s", tagKey)
+	syncLog.Info(ctx, "finished movie detail sync", "tag_key", tagKey, "synced", synced, "failed", failed)
+	return nil
 }
 
-func syncImage(thumb string) error {
+func syncImage(ctx context.Context, thumb string) error {
 	if thumb == "" || thumb == "/" {
 		return fmt.Errorf("invalid thumbnail URL")
 	}
@@ -136,7 +261,7 @@ func syncImage(thumb string) error {
 	cleanThumb = strings.TrimPrefix(cleanThumb, "/")
 
 	// Gọi FileService với path đã chuẩn hoá
-	_, _, _, err := file.FileService(cleanThumb)
+	_, _, _, err := file.FileService(ctx, cleanThumb)
 	if err != nil {
 		return fmt.Errorf("syncImage error: %v", err)
 	}
@@ -144,45 +269,24 @@ func syncImage(thumb string) error {
 	return nil
 }
 
-func extractItemsFromData(data map[string]interface{}) ([]interface{}, bool) {
-	rawData, ok := data["data"]
-	if !ok {
-		return nil, false
-	}
-
-	// Nếu rawData là slice luôn (trường hợp search)
-	if items, ok := rawData.([]interface{}); ok {
-		return items, true
-	}
-
-	// Nếu là object có "items" (trường hợp danh sách)
-	if m, ok := rawData.(map[string]interface{}); ok {
-		if items, ok := m["items"].([]interface{}); ok {
-			return items, true
-		}
-	}
-
-	return nil, false
-}
-
-func FetchAndUpdateThumbnails() {
+func FetchAndUpdateThumbnails(ctx context.Context) error {
 	var movies []movies2.Movie
-	if err := config.DB.Select("id", "slug", "thumb_url", "poster_url").Find(&movies).Error; err != nil {
-		fmt.Printf("[ImageSync] Error fetching movies: %v\n", err)
-		return
+	if err := config.DB.WithContext(ctx).Select("id", "slug", "thumb_url", "poster_url").Find(&movies).Error; err != nil {
+		return fmt.Errorf("failed to fetch movies: %w", err)
 	}
 
-	fmt.Printf("[ImageSync] Found %d movies to process\n", len(movies))
+	imageLog.Info(ctx, "processing movie images", "movies", len(movies))
 
 	for _, movie := range movies {
-		processImageField(movie.Slug, "thumb_url", movie.ThumbURL)
-		processImageField(movie.Slug, "poster_url", movie.PosterURL)
+		processImageField(ctx, movie.Slug, "thumb_url", movie.ThumbURL)
+		processImageField(ctx, movie.Slug, "poster_url", movie.PosterURL)
 	}
+	return nil
 }
 
-func processImageField(slug string, fieldName string, originalURL string) {
+func processImageField(ctx context.Context, slug string, fieldName string, originalURL string) {
 	if originalURL == "" || originalURL == "/" {
-		fmt.Printf("[ImageSync] Skipping empty %s for slug %s\n", fieldName, slug)
+		imageLog.Debug(ctx, "skipping empty image", "slug", slug, "field", fieldName)
 		return
 	}
 
@@ -193,7 +297,7 @@ func processImageField(slug string, fieldName string, originalURL string) {
 		// Dạng full URL → trích xuất phần path
 		parsed, err := url.Parse(originalURL)
 		if err != nil {
-			fmt.Printf("[ImageSync] Invalid URL in %s for slug %s: %v\n", fieldName, slug, err)
+			imageLog.Warn(ctx, "invalid image URL", "slug", slug, "field", fieldName, "error", err)
 			return
 		}
 		remoteURL = fmt.Sprintf("https://phimimg.com%s", parsed.Path)
@@ -203,29 +307,29 @@ func processImageField(slug string, fieldName string, originalURL string) {
 		remoteURL = fmt.Sprintf("https://phimimg.com/%s", cleanPath)
 
 	default:
-		fmt.Printf("[ImageSync] Unrecognized %s format for slug %s: %s\n", fieldName, slug, originalURL)
+		imageLog.Warn(ctx, "unrecognized image URL", "slug", slug, "field", fieldName, "url", originalURL)
 		return
 	}
 
-	newPath, err := utils.DownloadImageIfNotExist(remoteURL)
+	newPath, err := utils.DownloadImageIfNotExist(ctx, remoteURL)
 	if err != nil {
-		fmt.Printf("[ImageSync] Error downloading %s for slug %s: %v\n", fieldName, slug, err)
+		imageLog.Warn(ctx, "failed to download image", "slug", slug, "field", fieldName, "error", err)
 		return
 	}
 
 	// Nếu URL đã thay đổi → cập nhật DB
 	if newPath != originalURL {
 		if err := updateImageField(slug, fieldName, newPath); err != nil {
-			fmt.Printf("[ImageSync] Failed to update %s for slug %s: %v\n", fieldName, slug, err)
+			imageLog.Error(ctx, "failed to update image", "slug", slug, "field", fieldName, "error", err)
 			return
 		}
-		fmt.Printf("[ImageSync] Updated %s for slug %s to %s\n", fieldName, slug, newPath)
+		imageLog.Info(ctx, "image updated", "slug", slug, "field", fieldName, "path", newPath)
 	} else {
-		fmt.Printf("[ImageSync] %s for slug %s already up to date\n", fieldName, slug)
+		imageLog.Debug(ctx, "image up to date", "slug", slug, "field", fieldName)
 	}
 
-	if err := syncImage(newPath); err != nil {
-		fmt.Printf("[ImageSync] syncImage failed for slug %s: %v\n", slug, err)
+	if err := syncImage(ctx, newPath); err != nil {
+		imageLog.Warn(ctx, "failed to sync image", "slug", slug, "field", fieldName, "error", err)
 	}
 }
 
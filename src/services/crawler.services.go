@@ -0,0 +1,324 @@
+package services
+
+import (
+	"ani4s/src/cache"
+	"ani4s/src/config"
+	"ani4s/src/logger"
+	movieslib "ani4s/src/modules/movies/lib"
+	movies "ani4s/src/modules/movies/services"
+	"ani4s/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys used by the catalog crawler to checkpoint its progress
+const (
+	crawlerStateKey      = "crawler:state"        // hash: status, started_at, finished_at, movies, errors
+	crawlerCheckpointKey = "crawler:checkpoint"   // hash: source -> last fully processed page
+	crawlerDoneKey       = "crawler:done_sources" // set of finished sources
+	crawlerSeenKey       = "crawler:seen_slugs"   // set of slugs already mirrored in this run
+	crawlerLockKey       = "crawler:lock"
+
+	crawlerLockTTL     = 10 * time.Minute
+	crawlerPageRetries = 3
+)
+
+// CrawlOptions tunes how hard the crawler hits phimapi
+type CrawlOptions struct {
+	Concurrency int           // parallel detail fetches
+	Interval    time.Duration // minimum gap between two upstream requests
+	PageLimit   int           // items per category/country page
+	Fresh       bool          // discard checkpoints and start from page 1
+}
+
+// DefaultCrawlOptions follows the crawler settings (CRAWLER_CONCURRENCY and CRAWLER_RPS)
+func DefaultCrawlOptions() CrawlOptions {
+	settings := config.App.Crawler
+	return CrawlOptions{
+		Concurrency: settings.Concurrency,
+		Interval:    time.Duration(float64(time.Second) / settings.RPS),
+		PageLimit:   movieslib.MaxLimit,
+	}
+}
+
+// crawlSource is one paginated listing walked by the crawler
+type crawlSource struct {
+	name string
+	url  func(page int) string
+}
+
+var crawlerLog = logger.Named("crawler")
+
+type crawler struct {
+	opts    CrawlOptions
+	limiter *time.Ticker
+}
+
+// ErrCrawlRunning is returned when another process holds the crawler lock
+var ErrCrawlRunning = errors.New("catalog crawl already running")
+
+// RunCatalogCrawl mirrors the whole phimapi catalog into Postgres.
+// Progress is checkpointed per source and page, so an interrupted run resumes where it stopped.
+func RunCatalogCrawl(ctx context.Context, opts CrawlOptions) error {
+	rdb := config.RDB
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 200 * time.Millisecond
+	}
+	if opts.PageLimit <= 0 {
+		opts.PageLimit = movieslib.MaxLimit
+	}
+
+	// 1. Lock so replicas don't crawl twice
+	token := uuid.NewString()
+	ok, err := rdb.SetNX(ctx, crawlerLockKey, token, crawlerLockTTL).Result()
+	if err != nil {
+		return fmt.Errorf("failed to acquire crawler lock: %w", err)
+	}
+	if !ok {
+		return ErrCrawlRunning
+	}
+	defer releaseCrawlerLock(token)
+
+	// 2. Fresh run or resume
+	status, _ := rdb.HGet(ctx, crawlerStateKey, "status").Result()
+	if opts.Fresh || status != "running" {
+		_, _ = cache.DelKeys(ctx, rdb, crawlerCheckpointKey, crawlerDoneKey, crawlerSeenKey, crawlerStateKey)
+		rdb.HSet(ctx, crawlerStateKey, "status", "running", "started_at", time.Now().Unix())
+		crawlerLog.Info(ctx, "starting fresh catalog crawl")
+	} else {
+		crawlerLog.Info(ctx, "resuming catalog crawl from checkpoint")
+	}
+
+	c := &crawler{opts: opts, limiter: time.NewTicker(opts.Interval)}
+	defer c.limiter.Stop()
+
+	// 3. Walk every source
+	sources, err := c.sources(ctx)
+	if err != nil {
+		return err
+	}
+	for _, src := range sources {
+		if done, _ := rdb.SIsMember(ctx, crawlerDoneKey, src.name).Result(); done {
+			continue
+		}
+		if err := c.crawlSource(ctx, src); err != nil {
+			return fmt.Errorf("stopped at source %s: %w", src.name, err)
+		}
+		rdb.SAdd(ctx, crawlerDoneKey, src.name)
+	}
+
+	// 4. Done: keep the summary, drop the checkpoints
+	rdb.HSet(ctx, crawlerStateKey, "status", "finished", "finished_at", time.Now().Unix())
+	_, _ = cache.DelKeys(ctx, rdb, crawlerCheckpointKey, crawlerDoneKey, crawlerSeenKey)
+
+	stats, _ := rdb.HGetAll(ctx, crawlerStateKey).Result()
+	crawlerLog.Info(ctx, "catalog crawl finished", "movies", stats["movies"], "errors", stats["errors"])
+	return nil
+}
+
+// ResumeCatalogCrawl continues a crawl that was interrupted by a restart, if any
+func ResumeCatalogCrawl() {
+	status, err := config.RDB.HGet(config.Ctx, crawlerStateKey, "status").Result()
+	if err != nil || status != "running" {
+		return
+	}
+	_ = RunJob(jobsCtx, "crawl", func(ctx context.Context) error {
+		err := RunCatalogCrawl(ctx, DefaultCrawlOptions())
+		if errors.Is(err, ErrCrawlRunning) {
+			crawlerLog.Info(ctx, "crawl already running on another replica")
+			return nil
+		}
+		return err
+	})
+}
+
+// releaseLock deletes a lock only while it still holds token, in one step: a lock that
+// expired meanwhile may belong to another replica
+var releaseLock = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+func releaseCrawlerLock(token string) {
+	if err := releaseLock.Run(config.Ctx, config.RDB, []string{crawlerLockKey}, token).Err(); err != nil {
+		crawlerLog.Warn(config.Ctx, "failed to release crawler lock", "error", err)
+	}
+}
+
+// sources lists the newest feed followed by every category and country listing
+func (c *crawler) sources(ctx context.Context) ([]crawlSource, error) {
+	sources := []crawlSource{{
+		name: "newest",
+		url: func(page int) string {
+			return fmt.Sprintf("https://phimapi.com/danh-sach/phim-moi-cap-nhat?page=%d", page)
+		},
+	}}
+
+	categories, err := movies.ListAllCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	for _, cat := range categories.Items {
+		slug := cat.Slug
+		sources = append(sources, crawlSource{
+			name: "the-loai:" + slug,
+			url: func(page int) string {
+				return fmt.Sprintf("https://phimapi.com/v1/api/the-loai/%s?page=%d&limit=%d", slug, page, c.opts.PageLimit)
+			},
+		})
+	}
+
+	countries, err := movies.ListAllCountry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list countries: %w", err)
+	}
+	for _, country := range countries.Items {
+		slug := country.Slug
+		sources = append(sources, crawlSource{
+			name: "quoc-gia:" + slug,
+			url: func(page int) string {
+				return fmt.Sprintf("https://phimapi.com/v1/api/quoc-gia/%s?page=%d&limit=%d", slug, page, c.opts.PageLimit)
+			},
+		})
+	}
+	return sources, nil
+}
+
+// crawlSource walks the pages of src after its last checkpoint
+func (c *crawler) crawlSource(ctx context.Context, src crawlSource) error {
+	rdb := config.RDB
+
+	page := 1
+	if last, err := rdb.HGet(ctx, crawlerCheckpointKey, src.name).Int(); err == nil {
+		page = last + 1
+	}
+	crawlerLog.Info(ctx, "crawling source", "source", src.name, "from_page", page)
+
+	for totalPages := 0; totalPages == 0 || page <= totalPages; page++ {
+		items, pagination, err := c.fetchPage(ctx, src.url(page))
+		if err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		if len(items) == 0 {
+			break
+		}
+		totalPages = pagination.TotalPages
+
+		c.crawlItems(ctx, items)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rdb.HSet(ctx, crawlerCheckpointKey, src.name, page)
+		rdb.Expire(ctx, crawlerLockKey, crawlerLockTTL)
+		crawlerLog.Debug(ctx, "page done", "source", src.name, "page", page, "total_pages", totalPages)
+	}
+	return nil
+}
+
+// fetchPage fetches one listing page with a few retries
+func (c *crawler) fetchPage(ctx context.Context, targetURL string) ([]movieslib.MovieItem, utils.Pagination, error) {
+	var lastErr error
+	for attempt := 1; attempt <= crawlerPageRetries; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return nil, utils.Pagination{}, err
+		}
+		items, pagination, err := movies.FetchListPage(ctx, targetURL)
+		if err == nil {
+			return items, pagination, nil
+		}
+		lastErr = err
+		crawlerLog.Warn(ctx, "page fetch failed", "url", targetURL, "attempt", attempt, "error", err)
+
+		select {
+		case <-time.After(time.Duration(attempt) * 2 * time.Second):
+		case <-ctx.Done():
+			return nil, utils.Pagination{}, ctx.Err()
+		}
+	}
+	return nil, utils.Pagination{}, lastErr
+}
+
+// crawlItems mirrors the details of every unseen item with bounded concurrency. A slug is
+// marked seen once it is mirrored: one dropped by a shutdown or a failure is fetched
+// again when the page is resumed or shows up in a later source.
+func (c *crawler) crawlItems(ctx context.Context, items []movieslib.MovieItem) {
+	rdb := config.RDB
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for slug := range jobs {
+				if err := c.wait(ctx); err != nil {
+					continue
+				}
+				if err := c.crawlMovie(ctx, slug); err != nil {
+					crawlerLog.Warn(ctx, "failed to mirror movie", "slug", slug, "error", err)
+					rdb.HIncrBy(ctx, crawlerStateKey, "errors", 1)
+					continue
+				}
+				rdb.SAdd(ctx, crawlerSeenKey, slug)
+				rdb.HIncrBy(ctx, crawlerStateKey, "movies", 1)
+			}
+		}()
+	}
+
+	for _, item := range items {
+		if item.Slug == "" {
+			continue
+		}
+		// Same movie shows up in many listings: only fetch it once per run
+		if seen, err := rdb.SIsMember(ctx, crawlerSeenKey, item.Slug).Result(); err == nil && seen {
+			continue
+		}
+		select {
+		case jobs <- item.Slug:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// crawlMovie fetches and upserts the details of one movie
+func (c *crawler) crawlMovie(ctx context.Context, slug string) error {
+	data, err := movies.FetchMovieDetails(ctx, slug)
+	if err != nil {
+		return err
+	}
+	changed, err := movies.SaveMovieDetails(ctx, data)
+	if err != nil {
+		return err
+	}
+	if changed {
+		movies.InvalidateMovieDetails(slug)
+	}
+	return nil
+}
+
+// wait blocks until the rate limiter allows the next upstream request
+func (c *crawler) wait(ctx context.Context) error {
+	select {
+	case <-c.limiter.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"ani4s/src/config"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestReleaseCrawlerLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		holder string // token stored in the lock, empty when it expired
+		kept   bool
+	}{
+		{"own lock", "ours", false},
+		{"lock taken over by another replica", "theirs", true},
+		{"expired lock", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := miniredis.RunT(t)
+			previous := config.RDB
+			config.RDB = redis.NewClient(&redis.Options{Addr: srv.Addr()})
+			t.Cleanup(func() { config.RDB = previous })
+
+			if tt.holder != "" {
+				_ = srv.Set(crawlerLockKey, tt.holder)
+			}
+			releaseCrawlerLock("ours")
+
+			if got := srv.Exists(crawlerLockKey); got != tt.kept {
+				t.Errorf("lock exists = %v, want %v", got, tt.kept)
+			}
+			if tt.kept {
+				if v, _ := srv.Get(crawlerLockKey); v != tt.holder {
+					t.Errorf("lock holds %q, want %q", v, tt.holder)
+				}
+			}
+		})
+	}
+}
@@ -2,15 +2,21 @@ package services
 
 import (
 	"ani4s/src/lib"
+	"ani4s/src/logger"
+	"ani4s/src/metrics"
 	"ani4s/src/utils"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"log"
+	"net"
 	"net/http"
 )
 
+var socketLog = logger.Named("socket")
+
 // Upgrade configuration for WebSocket
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
@@ -36,45 +42,57 @@ func WebSocketHandler(c *gin.Context) {
 		return
 	}
 
+	// Every record of this session carries its connection id next to the request id
+	ctx := logger.With(c.Request.Context(), "conn_id", logger.NewID(), "user_id", userID)
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("Failed to upgrade WebSocket connection: %v", err)
+		socketLog.Warn(ctx, "failed to upgrade connection", "error", err)
 		return
 	}
+	metrics.WebSocketClients.Inc()
 	defer func() {
+		metrics.WebSocketClients.Dec()
 		conn.Close()
-		lib.RemoveUserSocket(utils.ConvertStringToUint(userID))
-		log.Printf("Client disconnected: %s", conn.RemoteAddr())
+		lib.RemoveUserSocket(utils.ConvertStringToUint(userID), conn)
+		socketLog.Info(ctx, "client disconnected", "remote_addr", conn.RemoteAddr().String())
 	}()
 
-	log.Printf("Client connected: %s", conn.RemoteAddr())
-	lib.SetUserSocket(utils.ConvertStringToUint(userID), conn)
+	socketLog.Info(ctx, "client connected", "remote_addr", conn.RemoteAddr().String())
+	lib.AddUserSocket(utils.ConvertStringToUint(userID), conn)
 
 	for {
 		// Read message from the client
 		messageType, message, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("Error reading message: %v", err)
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, net.ErrClosed) {
+				socketLog.Warn(ctx, "failed to read message", "error", err)
+			}
 			break
 		}
 
 		// Parse the JSON message
 		var wsMessage WebSocketMessage
 		if err := json.Unmarshal(message, &wsMessage); err != nil {
-			log.Printf("Error unmarshalling message: %v", err)
+			socketLog.Warn(ctx, "failed to unmarshal message", "error", err)
 			continue
 		}
 
 		// Process the message
-		handleMessage(conn, messageType, wsMessage)
+		handleMessage(ctx, conn, messageType, wsMessage)
 	}
+}
 
-	log.Printf("Client disconnected: %s", conn.RemoteAddr())
+// CloseWebSockets tells every client the server is restarting and closes its connection
+func CloseWebSockets(ctx context.Context) {
+	n := lib.CloseAllSockets(websocket.CloseServiceRestart, "server restarting")
+	socketLog.Info(ctx, "closed WebSocket connections", "count", n)
 }
 
 // handleMessage processes incoming WebSocket messages
-func handleMessage(conn *websocket.Conn, messageType int, wsMessage WebSocketMessage) {
+func handleMessage(ctx context.Context, conn *websocket.Conn, messageType int, wsMessage WebSocketMessage) {
 	var response WebSocketMessage
+	socketLog.Debug(ctx, "message received", "type", wsMessage.Type)
 
 	switch wsMessage.Type {
 	case "notice":
@@ -101,7 +119,7 @@ func handleMessage(conn *websocket.Conn, messageType int, wsMessage WebSocketMes
 
 	// Send the response as JSON
 	if err := sendJSONMessage(conn, messageType, response); err != nil {
-		log.Printf("Error sending message: %v", err)
+		socketLog.Warn(ctx, "failed to send message", "type", response.Type, "error", err)
 	}
 }
 
@@ -114,11 +132,11 @@ func sendJSONMessage(conn *websocket.Conn, messageType int, response WebSocketMe
 	return conn.WriteMessage(messageType, responseJSON)
 }
 
-// SendMessageToUser is a function using socket to send message
+// SendMessageToUser sends message to every open connection of userID
 func SendMessageToUser(userID uint, message WebSocketMessage) error {
-	conn, exists := lib.GetUserSocket(userID)
-	if !exists {
-		return fmt.Errorf("user %s not connected", userID)
+	conns := lib.GetUserSockets(userID)
+	if len(conns) == 0 {
+		return fmt.Errorf("user %d not connected", userID)
 	}
 
 	data, err := json.Marshal(message)
@@ -126,5 +144,11 @@ func SendMessageToUser(userID uint, message WebSocketMessage) error {
 		return err
 	}
 
-	return conn.WriteMessage(websocket.TextMessage, data)
+	var errs []error
+	for _, conn := range conns {
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin opens a span per statement under the span of the statement context, so
+// queries issued with db.WithContext(ctx) show up inside the request or job that ran them.
+// Statements outside any trace (migrations, calls without a context) are not traced.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	steps := []struct {
+		operation string
+		before    func(string) error
+		after     func(string) error
+	}{
+		{"create",
+			func(n string) error { return cb.Create().Before("gorm:create").Register(n, startSpan("create")) },
+			func(n string) error { return cb.Create().After("gorm:create").Register(n, endSpan) }},
+		{"query",
+			func(n string) error { return cb.Query().Before("gorm:query").Register(n, startSpan("query")) },
+			func(n string) error { return cb.Query().After("gorm:query").Register(n, endSpan) }},
+		{"update",
+			func(n string) error { return cb.Update().Before("gorm:update").Register(n, startSpan("update")) },
+			func(n string) error { return cb.Update().After("gorm:update").Register(n, endSpan) }},
+		{"delete",
+			func(n string) error { return cb.Delete().Before("gorm:delete").Register(n, startSpan("delete")) },
+			func(n string) error { return cb.Delete().After("gorm:delete").Register(n, endSpan) }},
+		{"row",
+			func(n string) error { return cb.Row().Before("gorm:row").Register(n, startSpan("row")) },
+			func(n string) error { return cb.Row().After("gorm:row").Register(n, endSpan) }},
+		{"raw",
+			func(n string) error { return cb.Raw().Before("gorm:raw").Register(n, startSpan("raw")) },
+			func(n string) error { return cb.Raw().After("gorm:raw").Register(n, endSpan) }},
+	}
+	for _, s := range steps {
+		if err := s.before("tracing:before_" + s.operation); err != nil {
+			return err
+		}
+		if err := s.after("tracing:after_" + s.operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startSpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if !trace.SpanContextFromContext(db.Statement.Context).IsValid() {
+			return
+		}
+		ctx, span := StartClient(db.Statement.Context, "db."+operation,
+			attribute.String("db.system", db.Dialector.Name()),
+			attribute.String("db.operation", operation),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endSpan(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
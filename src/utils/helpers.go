@@ -2,14 +2,17 @@ package utils
 
 import (
 	"ani4s/src/config"
-	movies "ani4s/src/modules/movies/models"
+	"ani4s/src/metrics"
+	"ani4s/src/tracing"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/minio/minio-go/v7"
+	"go.opentelemetry.io/otel/attribute"
 
 	"io/ioutil"
 	"math"
@@ -18,7 +21,7 @@ import (
 	"strings"
 )
 
-func Paginate(total int64, page, perPage int) (map[string]interface{}, error) {
+func Paginate(total int64, page, perPage int) (Pagination, error) {
 	// Avoid division by zero
 	if perPage <= 0 {
 		perPage = 1
@@ -39,13 +42,13 @@ func Paginate(total int64, page, perPage int) (map[string]interface{}, error) {
 	}
 
 	// Construct and return pagination data
-	return map[string]interface{}{
-		"current_page":   page,
-		"items_per_page": perPage,
-		"next_page":      nextPage,
-		"previous_page":  prevPage,
-		"total_count":    total,
-		"total_pages":    totalPages,
+	return Pagination{
+		CurrentPage:  page,
+		ItemsPerPage: perPage,
+		NextPage:     nextPage,
+		PreviousPage: prevPage,
+		TotalCount:   total,
+		TotalPages:   totalPages,
 	}, nil
 }
 
@@ -80,6 +83,7 @@ func ConvertStringToUint(str string) uint {
 // ServiceError to define return exception for system
 type ServiceError struct {
 	StatusCode int
+	Code       string
 	Message    string
 }
 
@@ -131,112 +135,6 @@ func BindJson(c *gin.Context, request interface{}) *ServiceError {
 	return nil
 }
 
-func IsValidApiResponse(raw map[string]interface{}) (map[string]interface{}, bool) {
-	// Nếu đã có data và data chứa item
-	if data, ok := raw["data"]; ok {
-		if dmap, ok := data.(map[string]interface{}); ok {
-			if items, hasItem := dmap["items"]; hasItem {
-				enriched := EnrichThumbFromDatabase(items)
-				dmap["items"] = enriched
-				return raw, true
-			}
-		}
-	}
-
-	// Nếu "items" ở root
-	if items, ok := raw["items"]; ok {
-		pagination := raw["pagination"]
-
-		enriched := EnrichThumbFromDatabase(items)
-
-		data := map[string]interface{}{
-			"items": enriched,
-		}
-		if pagination != nil {
-			data["pagination"] = pagination
-		}
-		return map[string]interface{}{
-			"data": data,
-		}, true
-	}
-
-	// Danh sách key cần kiểm tra
-	candidates := []string{"episodes", "movies", "list", "data"}
-
-	for _, key := range candidates {
-		if val, exists := raw[key]; exists {
-			enriched := EnrichThumbFromDatabase(val)
-			return map[string]interface{}{
-				"data": map[string]interface{}{
-					"items": map[string]interface{}{
-						key: enriched,
-					},
-				},
-			}, true
-		}
-	}
-
-	return nil, false
-}
-
-func EnrichThumbFromDatabase(items interface{}) interface{} {
-	db := config.DB
-	list, ok := items.([]interface{})
-	if !ok {
-		return items
-	}
-
-	// Bước 1: Collect all valid IDs
-	var ids []string
-	for _, i := range list {
-		if m, ok := i.(map[string]interface{}); ok {
-			if id, ok := m["_id"].(string); ok && id != "" {
-				ids = append(ids, id)
-			}
-		}
-	}
-
-	if len(ids) == 0 {
-		return items
-	}
-
-	// Bước 2: Query từ DB (Movie.ID -> ThumbURL)
-	type Result struct {
-		ID       string
-		ThumbURL string
-	}
-	var dbResults []Result
-	if err := db.
-		Model(&movies.Movie{}).
-		Select("id", "thumb_url").
-		Where("id IN ?", ids).
-		Find(&dbResults).Error; err != nil {
-		fmt.Printf("[EnrichThumb] DB error: %v\n", err)
-		return items
-	}
-
-	// Bước 3: Dùng map tra nhanh
-	thumbMap := make(map[string]string, len(dbResults))
-	for _, r := range dbResults {
-		if r.ThumbURL != "" {
-			thumbMap[r.ID] = r.ThumbURL
-		}
-	}
-
-	// Bước 4: Duyệt danh sách gốc và cập nhật nếu có DB match
-	for _, i := range list {
-		if m, ok := i.(map[string]interface{}); ok {
-			if id, ok := m["_id"].(string); ok {
-				if newThumb, exists := thumbMap[id]; exists {
-					m["thumb_url"] = newThumb
-				}
-			}
-		}
-	}
-
-	return list
-}
-
 func TranslateToEnglish(text string) (string, error) {
 	payload := map[string]string{
 		"q":      text,
@@ -277,8 +175,22 @@ func TranslateValuesInItems(items []interface{}) {
 	}
 }
 
+// TrackMinio opens the span of a MinIO call on object; the returned func records its
+// duration and outcome, and ends the span
+func TrackMinio(ctx context.Context, operation, object string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.StartClient(ctx, "minio."+operation,
+		attribute.String("minio.bucket", config.BucketName),
+		attribute.String("minio.object", object),
+	)
+	return ctx, func(err error) {
+		metrics.ObserveMinio(operation, start, err)
+		tracing.End(span, err)
+	}
+}
+
 // DownloadImageIfNotExist downloads an image if it's phimimg.com and not in local
-func DownloadImageIfNotExist(url string) (string, error) {
+func DownloadImageIfNotExist(ctx context.Context, url string) (string, error) {
 	const prefix = "https://phimimg.com/"
 	if !strings.HasPrefix(url, prefix) {
 		return url, nil
@@ -290,7 +202,9 @@ func DownloadImageIfNotExist(url string) (string, error) {
 	relativePath := strings.TrimPrefix(url, prefix)
 
 	// Kiểm tra object đã tồn tại chưa
-	_, err := minioClient.StatObject(context.Background(), bucketName, relativePath, minio.GetObjectOptions{})
+	opCtx, done := TrackMinio(ctx, "stat", relativePath)
+	_, err := minioClient.StatObject(opCtx, bucketName, relativePath, minio.GetObjectOptions{})
+	done(err)
 	if err == nil {
 		return relativePath, nil // đã có ảnh
 	}
@@ -307,26 +221,32 @@ func DownloadImageIfNotExist(url string) (string, error) {
 	}
 
 	// Kiểm tra và tạo bucket nếu chưa có
-	exists, err := minioClient.BucketExists(context.Background(), bucketName)
+	opCtx, done = TrackMinio(ctx, "bucket_exists", "")
+	exists, err := minioClient.BucketExists(opCtx, bucketName)
+	done(err)
 	if err != nil {
 		return "", fmt.Errorf("failed to check/create bucket: %w", err)
 	}
 	if !exists {
-		err = minioClient.MakeBucket(context.Background(), bucketName, minio.MakeBucketOptions{})
+		opCtx, done = TrackMinio(ctx, "make_bucket", "")
+		err = minioClient.MakeBucket(opCtx, bucketName, minio.MakeBucketOptions{})
+		done(err)
 		if err != nil {
 			return "", fmt.Errorf("failed to create bucket: %w", err)
 		}
 	}
 
 	// Lưu ảnh lên MinIO bucket
+	opCtx, done = TrackMinio(ctx, "put", relativePath)
 	_, err = minioClient.PutObject(
-		context.Background(),
+		opCtx,
 		bucketName,
 		relativePath,
 		resp.Body,
 		resp.ContentLength,
 		minio.PutObjectOptions{ContentType: resp.Header.Get("Content-Type")},
 	)
+	done(err)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload image to minio: %w", err)
 	}
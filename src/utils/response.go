@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned in Response.Error.Code
+const (
+	ErrCodeBadRequest   = "bad_request"
+	ErrCodeNotFound     = "not_found"
+	ErrCodeUpstream     = "upstream_error"
+	ErrCodeInternal     = "internal_error"
+	ErrCodeValidation   = "validation_failed"
+	ErrCodeUnauthorized = "unauthorized"
+	ErrCodeDisabled     = "disabled"
+)
+
+// Pagination is the pagination block shared by every list response
+type Pagination struct {
+	CurrentPage  int   `json:"current_page"`
+	ItemsPerPage int   `json:"items_per_page"`
+	NextPage     *int  `json:"next_page"`
+	PreviousPage *int  `json:"previous_page"`
+	TotalCount   int64 `json:"total_count"`
+	TotalPages   int   `json:"total_pages"`
+}
+
+// Meta describes where a payload came from
+type Meta struct {
+	FromCache  bool   `json:"from_cache"`
+	RequestURL string `json:"request_url,omitempty"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// RequestIDKey is the gin context key holding the X-Request-ID of the request
+const RequestIDKey = "request_id"
+
+// ErrorInfo is the error block of a failed response; RequestID lets a client quote the failing request
+type ErrorInfo struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Response is the envelope used by every JSON handler
+type Response struct {
+	Success    bool        `json:"success"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Meta       *Meta       `json:"meta,omitempty"`
+	Error      *ErrorInfo  `json:"error,omitempty"`
+}
+
+// RespondSuccess writes a 200 envelope with the given data
+func RespondSuccess(c *gin.Context, data interface{}, pagination *Pagination, meta *Meta) {
+	c.JSON(http.StatusOK, Response{
+		Success:    true,
+		Data:       data,
+		Pagination: pagination,
+		Meta:       meta,
+	})
+}
+
+// RespondCacheable writes a 200 envelope with Cache-Control and an ETag derived from the payload.
+// The ETag ignores Meta.FromCache so a cache hit and the fill that produced it share a tag,
+// and a matching If-None-Match is answered with 304.
+func RespondCacheable(c *gin.Context, data interface{}, pagination *Pagination, meta *Meta, maxAge time.Duration) {
+	res := Response{
+		Success:    true,
+		Data:       data,
+		Pagination: pagination,
+	}
+	if meta != nil {
+		stable := *meta
+		stable.FromCache = false
+		res.Meta = &stable
+	}
+
+	payload, err := json.Marshal(res)
+	if err != nil {
+		RespondSuccess(c, data, pagination, meta)
+		return
+	}
+	sum := sha1.Sum(payload)
+	etag := `W/"` + hex.EncodeToString(sum[:]) + `"`
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	res.Meta = meta
+	c.JSON(http.StatusOK, res)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (or *)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// RespondError writes a failed envelope with the given status and code
+func RespondError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, Response{
+		Success: false,
+		Error: &ErrorInfo{
+			Code:      code,
+			Message:   message,
+			RequestID: c.GetString(RequestIDKey),
+		},
+	})
+}
+
+// RespondServiceError maps err to an envelope, honouring ValidationError (422) and ServiceError status and code
+func RespondServiceError(c *gin.Context, err error) {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		c.JSON(http.StatusUnprocessableEntity, Response{
+			Success: false,
+			Error: &ErrorInfo{
+				Code:      ErrCodeValidation,
+				Message:   "request validation failed",
+				Details:   ve.Fields,
+				RequestID: c.GetString(RequestIDKey),
+			},
+		})
+		return
+	}
+
+	var se *ServiceError
+	if errors.As(err, &se) {
+		code := se.Code
+		if code == "" {
+			code = codeForStatus(se.StatusCode)
+		}
+		if se.StatusCode >= http.StatusInternalServerError {
+			_ = c.Error(err)
+		}
+		RespondError(c, se.StatusCode, code, se.Message)
+		return
+	}
+	// Server-side failures reach the access log through c.Errors
+	_ = c.Error(err)
+	RespondError(c, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+}
+
+func codeForStatus(status int) string {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrCodeNotFound
+	case status == http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case status == http.StatusBadGateway:
+		return ErrCodeUpstream
+	case status >= 400 && status < 500:
+		return ErrCodeBadRequest
+	default:
+		return ErrCodeInternal
+	}
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field of a request; it is answered with 422
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add records an invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// OrNil returns e when it holds field errors, nil otherwise
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func init() {
+	// Report binding tag failures with the json field name instead of the Go field name
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// BindError converts a gin binding error: tag failures become a ValidationError,
+// malformed bodies or query values a 400 ServiceError
+func BindError(err error) error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		out := &ValidationError{}
+		for _, fe := range verrs {
+			if fe.Tag() == "required" {
+				out.Add(fe.Field(), "is required")
+				continue
+			}
+			out.Add(fe.Field(), fmt.Sprintf("failed on the '%s' rule", fe.Tag()))
+		}
+		return out
+	}
+	return &ServiceError{
+		StatusCode: http.StatusBadRequest,
+		Code:       ErrCodeBadRequest,
+		Message:    "Invalid request parameters: " + err.Error(),
+	}
+}